@@ -3,14 +3,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
-	"github.com/go-vgo/robotgo"
+	"github.com/nizarmah/jarvis/internal/actuator"
+	"github.com/nizarmah/jarvis/internal/command"
+	"github.com/nizarmah/jarvis/internal/config"
+	"github.com/nizarmah/jarvis/internal/dictation"
 	"github.com/nizarmah/jarvis/internal/env"
+	"github.com/nizarmah/jarvis/internal/keymap"
+	"github.com/nizarmah/jarvis/internal/macro"
+	"github.com/nizarmah/jarvis/internal/mpris"
+	"github.com/nizarmah/jarvis/internal/protocol"
+	"github.com/nizarmah/jarvis/internal/scheduler"
 	"github.com/nizarmah/jarvis/internal/server"
 )
 
@@ -28,11 +40,79 @@ func main() {
 	)
 	defer cancel()
 
+	// Initialize the actuator.
+	act, err := actuator.New(actuator.Config{
+		Backend: e.ActuatorBackend,
+		Debug:   e.ActuatorDebug,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Ensure the skip ad button position is a point.
+	if n := len(e.SkipAdPosition); n != 0 && n != 2 {
+		log.Fatalf("SKIP_AD_POSITION must be x,y, got %d values", n)
+	}
+
+	// Initialize the keymap.
+	keys, err := keymap.New(keymap.Config{
+		Debug:    e.KeymapDebug,
+		Profile:  e.KeymapProfile,
+		Default:  e.KeymapDefault,
+		Profiles: keymap.Profiles,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the media player client, optional since it needs a session bus.
+	var media *mpris.Client
+	if e.MprisEnabled {
+		media, err = mpris.NewClient(mpris.Config{
+			Debug:  e.MprisDebug,
+			Player: e.MprisPlayer,
+		})
+		if err != nil {
+			log.Println(fmt.Sprintf("media player control disabled, falling back to keys: %v", err))
+		} else {
+			defer media.Close()
+		}
+	}
+
+	// Initialize the scheduler, which runs the commands like the server does, without interrupting the macros.
+	var (
+		registry *command.Registry
+		macros   *macro.Runner
+	)
+
+	sched, err := scheduler.New(scheduler.Config{
+		Debug: e.SchedulerDebug,
+		Dispatch: func(ctx context.Context, inv command.Invocation) (string, error) {
+			return handleCommand(ctx, registry, macros, inv, false)
+		},
+		MaxLate: e.SchedulerMaxLate,
+		Path:    e.SchedulerFile,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the command registry.
+	registry, macros, err = createRegistry(e, act, keys, media, sched)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Start the scheduler, after the registry can run the saved commands.
+	if err := sched.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize the server.
 	server := server.NewTCPServer(server.TCPServerConfig{
 		Address:   e.ExecutorAddress,
 		Debug:     e.ExecutorDebug,
-		OnMessage: createMessageHandler(e),
+		OnMessage: createMessageHandler(e, registry, macros),
 	})
 
 	// Start the server.
@@ -45,63 +125,758 @@ func main() {
 
 	// Wait for Ctrl+C or kill from context.
 	<-ctx.Done()
+
+	// Wait for the running macro to stop, the context interrupts it.
+	macros.Wait()
+
 	log.Println("Context cancelled — exiting.")
 }
 
+// errNoMedia is returned by the media commands when the media player client is unavailable.
+var errNoMedia = errors.New("media player control is unavailable")
+
+// createRegistry creates the command registry and binds the handlers.
+// It also returns the runner of the user-defined macros.
+// The media player client is nil when unavailable.
+func createRegistry(e *env.Env, act actuator.Actuator, keys *keymap.Keymap, media *mpris.Client, sched *scheduler.Scheduler) (*command.Registry, *macro.Runner, error) {
+	registry, err := command.NewDefaultRegistry()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create registry: %w", err)
+	}
+
+	// Register the user-defined commands, with their handlers.
+	cfg, err := config.Load(e.ConfigFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	macros, err := cfg.Register(registry, e.CommandDebug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Remember the dictated text to undo it.
+	typist := dictation.NewTypist(act, e.CommandDebug)
+
+	// Remember the mute state, since applications toggle it with a single key.
+	toggles := keymap.NewToggles()
+
+	handlers := map[string]command.HandlerFunc{
+		"pause_video": func(ctx context.Context, _ command.Values) (string, error) {
+			return pauseVideo(ctx, act, keys, media, e.CommandDebug)
+		},
+		"play_video": func(ctx context.Context, _ command.Values) (string, error) {
+			return playVideo(ctx, act, keys, media, e.CommandDebug)
+		},
+		"seek_forward": func(_ context.Context, args command.Values) (string, error) {
+			return "", seekVideo(act, keys, e.CommandDebug, args.Duration("seconds"))
+		},
+		"seek_backward": func(_ context.Context, args command.Values) (string, error) {
+			return "", seekVideo(act, keys, e.CommandDebug, -args.Duration("seconds"))
+		},
+		"set_volume": func(_ context.Context, args command.Values) (string, error) {
+			return "", setVolume(act, keys, e.CommandDebug, args.Int("level"))
+		},
+		"set_speed": func(_ context.Context, args command.Values) (string, error) {
+			return "", setSpeed(act, keys, e.CommandDebug, args.Number("speed"))
+		},
+		"jump_to_percent": func(_ context.Context, args command.Values) (string, error) {
+			return "", jumpVideo(act, keys, e.CommandDebug, args.Int("percent"))
+		},
+		"next_video": func(_ context.Context, _ command.Values) (string, error) {
+			return "", pressAction(act, keys, e.CommandDebug, keymap.ActionNext)
+		},
+		"previous_video": func(_ context.Context, _ command.Values) (string, error) {
+			return "", pressAction(act, keys, e.CommandDebug, keymap.ActionPrevious)
+		},
+		"mute_video": func(_ context.Context, _ command.Values) (string, error) {
+			return setMute(act, keys, toggles, e.CommandDebug, true)
+		},
+		"unmute_video": func(_ context.Context, _ command.Values) (string, error) {
+			return setMute(act, keys, toggles, e.CommandDebug, false)
+		},
+		"volume_up": func(_ context.Context, args command.Values) (string, error) {
+			return "", changeVolume(act, keys, e.CommandDebug, args.Int("amount"))
+		},
+		"volume_down": func(_ context.Context, args command.Values) (string, error) {
+			return "", changeVolume(act, keys, e.CommandDebug, -args.Int("amount"))
+		},
+		"toggle_fullscreen": func(_ context.Context, _ command.Values) (string, error) {
+			return "", pressAction(act, keys, e.CommandDebug, keymap.ActionFullscreen)
+		},
+		"toggle_theater_mode": func(_ context.Context, _ command.Values) (string, error) {
+			return "", pressAction(act, keys, e.CommandDebug, keymap.ActionTheater)
+		},
+		"toggle_captions": func(_ context.Context, _ command.Values) (string, error) {
+			return "", pressAction(act, keys, e.CommandDebug, keymap.ActionCaptions)
+		},
+		"speed_up": func(_ context.Context, args command.Values) (string, error) {
+			return "", changeSpeed(act, keys, e.CommandDebug, args.Number("amount"))
+		},
+		"speed_down": func(_ context.Context, args command.Values) (string, error) {
+			return "", changeSpeed(act, keys, e.CommandDebug, -args.Number("amount"))
+		},
+		"skip_ad": func(_ context.Context, _ command.Values) (string, error) {
+			return "", skipAd(act, keys, e.CommandDebug, e.SkipAdPosition)
+		},
+		"type_text": func(_ context.Context, args command.Values) (string, error) {
+			return typist.Type(args.Text("text"))
+		},
+		"undo_dictation": func(_ context.Context, _ command.Values) (string, error) {
+			return typist.Undo()
+		},
+		"list_players": func(ctx context.Context, _ command.Values) (string, error) {
+			return listPlayers(ctx, media)
+		},
+		"play_media": func(ctx context.Context, args command.Values) (string, error) {
+			return playMedia(ctx, media, args.Text("player"))
+		},
+		"pause_media": func(ctx context.Context, args command.Values) (string, error) {
+			return pauseMedia(ctx, media, args.Text("player"))
+		},
+		"next_track": func(ctx context.Context, args command.Values) (string, error) {
+			return nextTrack(ctx, media, args.Text("player"))
+		},
+		"previous_track": func(ctx context.Context, args command.Values) (string, error) {
+			return previousTrack(ctx, media, args.Text("player"))
+		},
+		"seek_media": func(ctx context.Context, args command.Values) (string, error) {
+			offset := args.Duration("seconds")
+			if args.Bool("backward") {
+				offset = -offset
+			}
+
+			return seekMedia(ctx, media, args.Text("player"), offset)
+		},
+		"set_media_volume": func(ctx context.Context, args command.Values) (string, error) {
+			return setMediaVolume(ctx, media, args.Text("player"), args.Int("level"))
+		},
+		"set_shuffle": func(ctx context.Context, args command.Values) (string, error) {
+			return setShuffle(ctx, media, args.Text("player"), args.Bool("shuffle"))
+		},
+		"schedule_command": func(_ context.Context, args command.Values) (string, error) {
+			return scheduleCommand(registry, sched, args)
+		},
+		"list_scheduled_commands": func(_ context.Context, _ command.Values) (string, error) {
+			return listScheduledCommands(sched), nil
+		},
+		"cancel_scheduled_commands": func(_ context.Context, args command.Values) (string, error) {
+			return cancelScheduledCommands(sched, args.Text("command"))
+		},
+	}
+
+	for name, handler := range handlers {
+		if err := registry.Handle(name, handler); err != nil {
+			return nil, nil, fmt.Errorf("failed to bind handler: %w", err)
+		}
+	}
+
+	// Ensure every command can be executed.
+	if err := registry.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("failed to validate registry: %w", err)
+	}
+
+	return registry, macros, nil
+}
+
 // createMessageHandler creates a message handler.
-func createMessageHandler(e *env.Env) server.OnMessageFunc {
-	return func(ctx context.Context, msg string) error {
-		msg = strings.TrimSpace(strings.ToLower(msg))
+func createMessageHandler(e *env.Env, registry *command.Registry, macros *macro.Runner) server.OnMessageFunc {
+	return func(ctx context.Context, msg string) (string, error) {
+		start := time.Now()
 		if e.MessageHandlerDebug {
 			log.Printf("received message: %q", msg)
 		}
 
-		if err := handleCommand(ctx, e, msg); err != nil {
-			log.Println(fmt.Sprintf("error handling command: %v", err))
+		req, result, err := handleMessage(ctx, registry, macros, msg)
+		if err != nil {
+			log.Println(fmt.Sprintf("error handling message: %v", err))
 		}
 
-		return nil
+		reply, err := protocol.Encode(protocol.NewResponse(req.ID, result, err, time.Since(start)))
+		if err != nil {
+			log.Println(fmt.Sprintf("error encoding response: %v", err))
+			return "", nil
+		}
+
+		return string(reply), nil
+	}
+}
+
+// handleMessage decodes the request and handles its command.
+func handleMessage(ctx context.Context, registry *command.Registry, macros *macro.Runner, msg string) (protocol.Request, string, error) {
+	req, err := protocol.DecodeRequest(msg)
+	if err != nil {
+		return req, "", err
+	}
+
+	result, err := handleCommand(ctx, registry, macros, req.Invocation(), true)
+	if err != nil {
+		return req, "", fmt.Errorf("failed to handle command %q: %w", req.Command, err)
 	}
+
+	return req, result, nil
 }
 
 // handleCommand handles the command.
-func handleCommand(_ context.Context, e *env.Env, msg string) error {
-	switch msg {
-	case "pause_video":
-		return pauseVideo(e.CommandDebug)
+// A new command from the user interrupts the running macro, eg. while it waits between steps,
+// unless it only reports the state, eg. `list_scheduled_commands`.
+func handleCommand(ctx context.Context, registry *command.Registry, macros *macro.Runner, inv command.Invocation, interrupt bool) (string, error) {
+	// Only the name is case insensitive, text arguments keep their case.
+	inv.Name = strings.ToLower(inv.Name)
+
+	if cmd, ok := registry.Lookup(inv.Name); interrupt && ok && !cmd.ReadOnly {
+		macros.Interrupt()
+	}
+
+	return registry.Dispatch(ctx, inv)
+}
+
+// pauseVideo pauses the video.
+// It asks the media player of the focused window when available, so pausing a paused video does nothing,
+// otherwise it falls back to the pause key of the focused application, which usually toggles.
+func pauseVideo(ctx context.Context, act actuator.Actuator, keys *keymap.Keymap, media *mpris.Client, debug bool) (string, error) {
+	if player, ok := focusedPlayer(ctx, act, media, debug); ok {
+		player, changed, err := media.Pause(ctx, player)
+		if err == nil {
+			if !changed {
+				return fmt.Sprintf("%s already paused", player), nil
+			}
+
+			return fmt.Sprintf("paused %s", player), nil
+		}
+
+		if debug {
+			log.Println(fmt.Sprintf("failed to pause media player, falling back to keys: %v", err))
+		}
+	}
+
+	profile := focusedProfile(act, keys)
+	if err := tapAction(act, profile, keymap.ActionPause); err != nil {
+		return "", fmt.Errorf("failed to pause video: %w", err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("paused video: %s", profile.Name))
+	}
+
+	return "", nil
+}
+
+// playVideo plays the video.
+// It asks the media player of the focused window when available, so playing a playing video does nothing,
+// otherwise it falls back to the play key of the focused application, which usually toggles.
+func playVideo(ctx context.Context, act actuator.Actuator, keys *keymap.Keymap, media *mpris.Client, debug bool) (string, error) {
+	if player, ok := focusedPlayer(ctx, act, media, debug); ok {
+		player, changed, err := media.Play(ctx, player)
+		if err == nil {
+			if !changed {
+				return fmt.Sprintf("%s already playing", player), nil
+			}
 
-	case "play_video":
-		return playVideo(e.CommandDebug)
+			return fmt.Sprintf("played %s", player), nil
+		}
 
-	default:
-		log.Printf("unsupported command: %s", msg)
+		if debug {
+			log.Println(fmt.Sprintf("failed to play media player, falling back to keys: %v", err))
+		}
+	}
+
+	profile := focusedProfile(act, keys)
+	if err := tapAction(act, profile, keymap.ActionPlay); err != nil {
+		return "", fmt.Errorf("failed to play video: %w", err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("played video: %s", profile.Name))
+	}
+
+	return "", nil
+}
+
+// focusedPlayer returns the media player of the focused window, or the pinned one, for the video commands.
+// Any other player is left alone, eg. "pause the video" never pauses spotify in the background.
+func focusedPlayer(ctx context.Context, act actuator.Actuator, media *mpris.Client, debug bool) (string, bool) {
+	if media == nil {
+		return "", false
+	}
+
+	window, err := act.FocusedWindow()
+	if err != nil {
+		if debug {
+			log.Println(fmt.Sprintf("failed to get focused window, falling back to keys: %v", err))
+		}
+
+		return "", false
+	}
+
+	player, err := media.Focused(ctx, window.Process)
+	if err != nil {
+		if debug {
+			log.Println(fmt.Sprintf("no media player for the focused window, falling back to keys: %v", err))
+		}
+
+		return "", false
+	}
+
+	return player, true
+}
+
+// seekVideo seeks the video forward, or backward when the offset is negative.
+// The offset is rounded to the smallest seek step of the focused application, eg. 5 seconds on YouTube, at least one step.
+func seekVideo(act actuator.Actuator, keys *keymap.Keymap, debug bool, offset time.Duration) error {
+	profile := focusedProfile(act, keys)
+
+	seekKeys, err := profile.Seek(offset.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to seek video: %w", err)
+	}
+
+	if err := tapKeys(act, seekKeys); err != nil {
+		return fmt.Errorf("failed to seek video: %w", err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("seeked video: %s %s", profile.Name, offset))
 	}
 
 	return nil
 }
 
-// pauseVideo pauses the video.
-func pauseVideo(debug bool) error {
-	if err := robotgo.KeyTap("k"); err != nil {
-		return fmt.Errorf("failed to pause video: %w", err)
+// setVolume sets the volume of the video.
+// Applications change the volume by steps and have no absolute volume,
+// so the volume is lowered to 0% first, then raised to the level.
+func setVolume(act actuator.Actuator, keys *keymap.Keymap, debug bool, level int) error {
+	profile := focusedProfile(act, keys)
+
+	volumeKeys, err := profile.Volume(level)
+	if err != nil {
+		return fmt.Errorf("failed to set volume: %w", err)
+	}
+
+	if err := tapKeys(act, volumeKeys); err != nil {
+		return fmt.Errorf("failed to set volume: %w", err)
 	}
 
 	if debug {
-		log.Println("paused video")
+		log.Println(fmt.Sprintf("set volume: %s %d%%", profile.Name, level))
 	}
 
 	return nil
 }
 
-// playVideo plays the video.
-func playVideo(debug bool) error {
-	if err := robotgo.KeyTap("k"); err != nil {
-		return fmt.Errorf("failed to play video: %w", err)
+// setSpeed sets the playback speed of the video.
+// Applications change the speed by steps and have no absolute speed,
+// so the speed is lowered to the minimum first, then raised to the speed.
+func setSpeed(act actuator.Actuator, keys *keymap.Keymap, debug bool, speed float64) error {
+	profile := focusedProfile(act, keys)
+
+	speedKeys, err := profile.Speed(speed)
+	if err != nil {
+		return fmt.Errorf("failed to set speed: %w", err)
+	}
+
+	if err := tapKeys(act, speedKeys); err != nil {
+		return fmt.Errorf("failed to set speed: %w", err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("set speed: %s %.2f", profile.Name, speed))
+	}
+
+	return nil
+}
+
+// jumpVideo jumps to the position of the video, in percent.
+// YouTube jumps with the digit keys, so the position is rounded to the nearest 10%.
+func jumpVideo(act actuator.Actuator, keys *keymap.Keymap, debug bool, percent int) error {
+	profile := focusedProfile(act, keys)
+
+	key, err := profile.Jump(percent)
+	if err != nil {
+		return fmt.Errorf("failed to jump video: %w", err)
+	}
+
+	if err := tapKeys(act, []keymap.Key{key}); err != nil {
+		return fmt.Errorf("failed to jump video: %w", err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("jumped video: %s %d%%", profile.Name, percent))
+	}
+
+	return nil
+}
+
+// changeVolume changes the volume of the video by the percentage, lowering it when negative.
+func changeVolume(act actuator.Actuator, keys *keymap.Keymap, debug bool, percent int) error {
+	profile := focusedProfile(act, keys)
+
+	volumeKeys, err := profile.VolumeBy(percent)
+	if err != nil {
+		return fmt.Errorf("failed to change volume: %w", err)
+	}
+
+	if err := tapKeys(act, volumeKeys); err != nil {
+		return fmt.Errorf("failed to change volume: %w", err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("changed volume: %s %+d%%", profile.Name, percent))
+	}
+
+	return nil
+}
+
+// changeSpeed changes the playback speed of the video by the amount, lowering it when negative.
+func changeSpeed(act actuator.Actuator, keys *keymap.Keymap, debug bool, amount float64) error {
+	profile := focusedProfile(act, keys)
+
+	speedKeys, err := profile.SpeedBy(amount)
+	if err != nil {
+		return fmt.Errorf("failed to change speed: %w", err)
+	}
+
+	if err := tapKeys(act, speedKeys); err != nil {
+		return fmt.Errorf("failed to change speed: %w", err)
 	}
 
 	if debug {
-		log.Println("played video")
+		log.Println(fmt.Sprintf("changed speed: %s %+.2f", profile.Name, amount))
 	}
 
 	return nil
 }
+
+// skipAd skips the ad.
+// YouTube has no skip shortcut, so it clicks the skip button at its screen position, when configured.
+func skipAd(act actuator.Actuator, keys *keymap.Keymap, debug bool, position []int) error {
+	profile := focusedProfile(act, keys)
+
+	if key, err := profile.Key(keymap.ActionSkipAd); err == nil {
+		if err := tapKeys(act, []keymap.Key{key}); err != nil {
+			return fmt.Errorf("failed to skip ad: %w", err)
+		}
+
+		return nil
+	}
+
+	if len(position) != 2 {
+		return fmt.Errorf("failed to skip ad: %w: %s has no skip key and SKIP_AD_POSITION is empty", keymap.ErrUnsupported, profile.Name)
+	}
+
+	if err := act.Move(position[0], position[1]); err != nil {
+		return fmt.Errorf("failed to move to skip button: %w", err)
+	}
+
+	if err := act.Click("left", false); err != nil {
+		return fmt.Errorf("failed to click skip button: %w", err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("skipped ad: clicked %d,%d", position[0], position[1]))
+	}
+
+	return nil
+}
+
+// setMute mutes or unmutes the video.
+// Applications usually toggle both with the same key, so it remembers the state it set,
+// eg. unmuting an unmuted video does nothing.
+func setMute(act actuator.Actuator, keys *keymap.Keymap, toggles *keymap.Toggles, debug bool, muted bool) (string, error) {
+	profile := focusedProfile(act, keys)
+
+	action, state := keymap.ActionUnmute, "unmuted"
+	if muted {
+		action, state = keymap.ActionMute, "muted"
+	}
+
+	key, err := profile.Key(action)
+	if err != nil {
+		return "", fmt.Errorf("failed to press %s key: %w", action, err)
+	}
+
+	toggle := profile.Name + " mute"
+	if profile.Keys[keymap.ActionMute] == profile.Keys[keymap.ActionUnmute] && !toggles.Set(toggle, muted) {
+		return fmt.Sprintf("%s already %s", profile.Name, state), nil
+	}
+
+	if err := tapKeys(act, []keymap.Key{key}); err != nil {
+		toggles.Forget(toggle)
+		return "", fmt.Errorf("failed to press %s key: %w", action, err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("pressed %s key: %s", action, profile.Name))
+	}
+
+	return "", nil
+}
+
+// pressAction taps the key of the action in the focused application, eg. `f` for fullscreen on YouTube.
+func pressAction(act actuator.Actuator, keys *keymap.Keymap, debug bool, action keymap.Action) error {
+	profile := focusedProfile(act, keys)
+	if err := tapAction(act, profile, action); err != nil {
+		return fmt.Errorf("failed to press %s key: %w", action, err)
+	}
+
+	if debug {
+		log.Println(fmt.Sprintf("pressed %s key: %s", action, profile.Name))
+	}
+
+	return nil
+}
+
+// focusedProfile returns the keymap profile of the focused window.
+func focusedProfile(act actuator.Actuator, keys *keymap.Keymap) keymap.Profile {
+	window, err := act.FocusedWindow()
+	if err != nil {
+		log.Println(fmt.Sprintf("failed to get focused window, using the default keymap profile: %v", err))
+	}
+
+	return keys.Select(window.Title, window.Process)
+}
+
+// tapAction taps the key bound to the action in the profile.
+func tapAction(act actuator.Actuator, profile keymap.Profile, action keymap.Action) error {
+	key, err := profile.Key(action)
+	if err != nil {
+		return err
+	}
+
+	return tapKeys(act, []keymap.Key{key})
+}
+
+// tapKeys taps the keys in order.
+func tapKeys(act actuator.Actuator, keys []keymap.Key) error {
+	for _, key := range keys {
+		name, modifiers := key.Split()
+		if err := act.KeyTap(name, modifiers...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listPlayers lists the media players and their playback status.
+func listPlayers(ctx context.Context, media *mpris.Client) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	players, err := media.List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list media players: %w", err)
+	}
+
+	if len(players) == 0 {
+		return "no media players", nil
+	}
+
+	parts := make([]string, 0, len(players))
+	for _, player := range players {
+		parts = append(parts, fmt.Sprintf("%s (%s)", player.Name, strings.ToLower(string(player.Status))))
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+// playMedia plays the media player, or the active one when empty.
+func playMedia(ctx context.Context, media *mpris.Client, player string) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	player, changed, err := media.Play(ctx, player)
+	if err != nil {
+		return "", fmt.Errorf("failed to play media: %w", err)
+	}
+
+	if !changed {
+		return fmt.Sprintf("%s already playing", player), nil
+	}
+
+	return fmt.Sprintf("played %s", player), nil
+}
+
+// pauseMedia pauses the media player, or the active one when empty.
+func pauseMedia(ctx context.Context, media *mpris.Client, player string) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	player, changed, err := media.Pause(ctx, player)
+	if err != nil {
+		return "", fmt.Errorf("failed to pause media: %w", err)
+	}
+
+	if !changed {
+		return fmt.Sprintf("%s already paused", player), nil
+	}
+
+	return fmt.Sprintf("paused %s", player), nil
+}
+
+// nextTrack skips to the next track of the media player, or the active one when empty.
+func nextTrack(ctx context.Context, media *mpris.Client, player string) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	player, err := media.Next(ctx, player)
+	if err != nil {
+		return "", fmt.Errorf("failed to skip to next track: %w", err)
+	}
+
+	return fmt.Sprintf("skipped %s to the next track", player), nil
+}
+
+// previousTrack goes back to the previous track of the media player, or the active one when empty.
+func previousTrack(ctx context.Context, media *mpris.Client, player string) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	player, err := media.Previous(ctx, player)
+	if err != nil {
+		return "", fmt.Errorf("failed to go back to previous track: %w", err)
+	}
+
+	return fmt.Sprintf("returned %s to the previous track", player), nil
+}
+
+// seekMedia seeks the media player, or the active one when empty, backward when the offset is negative.
+func seekMedia(ctx context.Context, media *mpris.Client, player string, offset time.Duration) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	player, position, err := media.Seek(ctx, player, offset)
+	if err != nil {
+		return "", fmt.Errorf("failed to seek media: %w", err)
+	}
+
+	return fmt.Sprintf("seeked %s to %s", player, position.Round(time.Second)), nil
+}
+
+// setMediaVolume sets the volume of the media player, or the active one when empty.
+func setMediaVolume(ctx context.Context, media *mpris.Client, player string, level int) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	player, volume, err := media.SetVolume(ctx, player, float64(level)/100)
+	if err != nil {
+		return "", fmt.Errorf("failed to set media volume: %w", err)
+	}
+
+	return fmt.Sprintf("set %s volume to %d%%", player, int(math.Round(volume*100))), nil
+}
+
+// setShuffle turns the shuffle of the media player, or the active one when empty, on or off.
+func setShuffle(ctx context.Context, media *mpris.Client, player string, shuffle bool) (string, error) {
+	if media == nil {
+		return "", errNoMedia
+	}
+
+	player, shuffle, err := media.SetShuffle(ctx, player, shuffle)
+	if err != nil {
+		return "", fmt.Errorf("failed to set shuffle: %w", err)
+	}
+
+	if shuffle {
+		return fmt.Sprintf("%s shuffle on", player), nil
+	}
+
+	return fmt.Sprintf("%s shuffle off", player), nil
+}
+
+// scheduleCommand schedules the command after the delay, or at the time of day.
+func scheduleCommand(registry *command.Registry, sched *scheduler.Scheduler, args command.Values) (string, error) {
+	if args.Has("delay") == args.Has("at") {
+		return "", fmt.Errorf("%w: either delay or at is required", command.ErrInvalidArgs)
+	}
+
+	inv, err := command.ParseInvocation(args.Text("command"))
+	if err != nil {
+		return "", fmt.Errorf("%w: command: %w", command.ErrInvalidArgs, err)
+	}
+
+	// Only the name is case insensitive, text arguments keep their case.
+	inv.Name = strings.ToLower(inv.Name)
+
+	// Scheduling the scheduler commands would only confuse.
+	if slices.ContainsFunc(command.SchedulerBuiltins, func(cmd command.Command) bool {
+		return cmd.Name == inv.Name
+	}) {
+		return "", fmt.Errorf("%w: command: %q cannot be scheduled", command.ErrInvalidArgs, inv.Name)
+	}
+
+	// Fail now rather than when it is due.
+	if _, _, err := registry.Parse(inv); err != nil {
+		return "", fmt.Errorf("%w: command: %w", command.ErrInvalidArgs, err)
+	}
+
+	now := time.Now()
+	at := now.Add(args.Duration("delay"))
+	if args.Has("at") {
+		at = scheduler.Next(now, args.Duration("at"))
+	}
+
+	job, err := sched.Schedule(inv, at)
+	if err != nil {
+		return "", fmt.Errorf("failed to schedule command: %w", err)
+	}
+
+	return fmt.Sprintf("scheduled %s at %s (#%s)", job.Command, formatTime(job.At, now), job.ID), nil
+}
+
+// listScheduledCommands lists the scheduled commands, the soonest first.
+func listScheduledCommands(sched *scheduler.Scheduler) string {
+	jobs := sched.List()
+	if len(jobs) == 0 {
+		return "no scheduled commands"
+	}
+
+	now := time.Now()
+
+	lines := make([]string, len(jobs))
+	for i, job := range jobs {
+		lines[i] = fmt.Sprintf("#%s %s at %s", job.ID, job.Command, formatTime(job.At, now))
+	}
+
+	return strings.Join(lines, "; ")
+}
+
+// cancelScheduledCommands cancels the scheduled commands with the name, or all of them when empty.
+func cancelScheduledCommands(sched *scheduler.Scheduler, name string) (string, error) {
+	name = strings.ToLower(name)
+
+	var filter func(scheduler.Job) bool
+	if name != "" {
+		filter = func(job scheduler.Job) bool {
+			inv, err := command.ParseInvocation(job.Command)
+			return err == nil && inv.Name == name
+		}
+	}
+
+	cancelled, err := sched.Cancel(filter)
+	if err != nil {
+		return "", fmt.Errorf("failed to cancel scheduled commands: %w", err)
+	}
+
+	if len(cancelled) == 0 {
+		return "no scheduled commands to cancel", nil
+	}
+
+	return fmt.Sprintf("cancelled %d scheduled commands", len(cancelled)), nil
+}
+
+// formatTime formats the time of day, with the day when it is not today.
+func formatTime(t, now time.Time) string {
+	if t.YearDay() == now.YearDay() && t.Year() == now.Year() {
+		return t.Format("15:04")
+	}
+
+	return t.Format("Mon 15:04")
+}
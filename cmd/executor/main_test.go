@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/actuator"
+	"github.com/nizarmah/jarvis/internal/command"
+	"github.com/nizarmah/jarvis/internal/env"
+	"github.com/nizarmah/jarvis/internal/executor"
+	"github.com/nizarmah/jarvis/internal/keymap"
+	"github.com/nizarmah/jarvis/internal/macro"
+	"github.com/nizarmah/jarvis/internal/scheduler"
+	"github.com/nizarmah/jarvis/internal/server"
+)
+
+// youtube is a focused YouTube tab.
+var youtube = actuator.Window{Title: "Video - YouTube - Mozilla Firefox", Process: "firefox"}
+
+// newTestExecutor creates the registry of a headless executor, recording the inputs, without media players.
+func newTestExecutor(t *testing.T, window actuator.Window) (*command.Registry, *macro.Runner, *actuator.Recorder) {
+	t.Helper()
+
+	e := &env.Env{
+		SkipAdPosition: []int{640, 360},
+	}
+
+	rec := actuator.NewRecorder(false)
+	rec.SetWindow(window)
+
+	keys, err := keymap.New(keymap.Config{
+		Default:  "youtube",
+		Profiles: keymap.Profiles,
+	})
+	if err != nil {
+		t.Fatalf("failed to create keymap: %v", err)
+	}
+
+	var (
+		registry *command.Registry
+		macros   *macro.Runner
+	)
+
+	sched, err := scheduler.New(scheduler.Config{
+		Dispatch: func(ctx context.Context, inv command.Invocation) (string, error) {
+			return handleCommand(ctx, registry, macros, inv, false)
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create scheduler: %v", err)
+	}
+
+	registry, macros, err = createRegistry(e, rec, keys, nil, sched)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	return registry, macros, rec
+}
+
+// inputs formats the recorded inputs, eg. `key_tap shift+n`.
+func inputs(rec *actuator.Recorder) []string {
+	recorded := rec.Inputs()
+
+	lines := make([]string, len(recorded))
+	for i, input := range recorded {
+		lines[i] = input.String()
+	}
+
+	return lines
+}
+
+func TestHandleMessageInputs(t *testing.T) {
+	tests := []struct {
+		name   string
+		window actuator.Window
+		msg    string
+		want   []string
+	}{
+		{
+			name:   "pause without media players",
+			window: youtube,
+			msg:    "pause_video",
+			want:   []string{"key_tap k"},
+		},
+		{
+			name:   "seek forward in steps",
+			window: youtube,
+			msg:    "seek_forward seconds=25s",
+			want:   []string{"key_tap l", "key_tap l", "key_tap right"},
+		},
+		{
+			name:   "seek less than the smallest step",
+			window: youtube,
+			msg:    "seek_forward seconds=2s",
+			want:   []string{"key_tap right"},
+		},
+		{
+			name:   "seek backward in vlc",
+			window: actuator.Window{Title: "movie.mkv - VLC media player", Process: "vlc"},
+			msg:    "seek_backward seconds=63s",
+			want:   []string{"key_tap ctrl+left", "key_tap shift+left"},
+		},
+		{
+			name:   "set volume from zero",
+			window: youtube,
+			msg:    "set_volume level=10",
+			want:   append(slices.Repeat([]string{"key_tap down"}, 20), "key_tap up", "key_tap up"),
+		},
+		{
+			name:   "jump to percent",
+			window: youtube,
+			msg:    "jump_to_percent percent=70",
+			want:   []string{"key_tap 7"},
+		},
+		{
+			name:   "speed up by default",
+			window: youtube,
+			msg:    "speed_up",
+			want:   []string{"key_tap shift+."},
+		},
+		{
+			name:   "next video",
+			window: youtube,
+			msg:    "NEXT_VIDEO",
+			want:   []string{"key_tap shift+n"},
+		},
+		{
+			name:   "skip ad by clicking",
+			window: youtube,
+			msg:    "skip_ad",
+			want:   []string{"move 640,360", "click left"},
+		},
+		{
+			name:   "json envelope",
+			window: youtube,
+			msg:    `{"version":1,"id":"1","command":"toggle_fullscreen","source":"test"}`,
+			want:   []string{"key_tap f"},
+		},
+		{
+			name:   "default profile for unknown windows",
+			window: actuator.Window{Title: "Terminal", Process: "kitty"},
+			msg:    "toggle_captions",
+			want:   []string{"key_tap c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, macros, rec := newTestExecutor(t, tt.window)
+
+			if _, _, err := handleMessage(context.Background(), registry, macros, tt.msg); err != nil {
+				t.Fatalf("handleMessage(%q) failed: %v", tt.msg, err)
+			}
+
+			if got := inputs(rec); !slices.Equal(got, tt.want) {
+				t.Errorf("handleMessage(%q) inputs = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleMessageErrorsRecordNothing(t *testing.T) {
+	tests := []struct {
+		name   string
+		window actuator.Window
+		msg    string
+	}{
+		{name: "unknown command", window: youtube, msg: "self_destruct"},
+		{name: "invalid argument", window: youtube, msg: "set_volume level=loud"},
+		{name: "missing argument", window: youtube, msg: "set_volume"},
+		{name: "unsupported by the profile", window: actuator.Window{Title: "Netflix"}, msg: "toggle_captions"},
+		{name: "media players unavailable", window: youtube, msg: "next_track"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, macros, rec := newTestExecutor(t, tt.window)
+
+			if _, _, err := handleMessage(context.Background(), registry, macros, tt.msg); err == nil {
+				t.Errorf("handleMessage(%q) succeeded, want an error", tt.msg)
+			}
+
+			if got := inputs(rec); len(got) != 0 {
+				t.Errorf("handleMessage(%q) inputs = %q, want none", tt.msg, got)
+			}
+		})
+	}
+}
+
+func TestRegistryDispatchInputs(t *testing.T) {
+	registry, _, rec := newTestExecutor(t, youtube)
+
+	for _, inv := range []command.Invocation{
+		{Name: "pause_video"},
+		{Name: "volume_down", Args: command.Args{"amount": "15"}},
+		{Name: "previous_video"},
+	} {
+		if _, err := registry.Dispatch(context.Background(), inv); err != nil {
+			t.Fatalf("Dispatch(%s) failed: %v", inv, err)
+		}
+	}
+
+	want := []string{"key_tap k", "key_tap down", "key_tap down", "key_tap down", "key_tap shift+p"}
+	if got := inputs(rec); !slices.Equal(got, want) {
+		t.Errorf("inputs = %q, want %q", got, want)
+	}
+
+	rec.Reset()
+	if got := rec.Inputs(); len(got) != 0 {
+		t.Errorf("inputs after reset = %q, want none", got)
+	}
+}
+
+func TestMuteUnmute(t *testing.T) {
+	registry, macros, rec := newTestExecutor(t, youtube)
+
+	steps := []struct {
+		msg        string
+		wantTapped bool
+		wantResult string
+	}{
+		// The state is unknown, asking to unmute means it is muted.
+		{msg: "unmute_video", wantTapped: true},
+		{msg: "unmute_video", wantResult: "youtube already unmuted"},
+		{msg: "mute_video", wantTapped: true},
+		{msg: "mute_video", wantResult: "youtube already muted"},
+		{msg: "unmute_video", wantTapped: true},
+	}
+
+	for _, step := range steps {
+		rec.Reset()
+
+		_, result, err := handleMessage(context.Background(), registry, macros, step.msg)
+		if err != nil {
+			t.Fatalf("handleMessage(%q) failed: %v", step.msg, err)
+		}
+
+		var want []string
+		if step.wantTapped {
+			want = []string{"key_tap m"}
+		}
+
+		if got := inputs(rec); !slices.Equal(got, want) {
+			t.Errorf("handleMessage(%q) inputs = %q, want %q", step.msg, got, want)
+		}
+
+		if result != step.wantResult {
+			t.Errorf("handleMessage(%q) result = %q, want %q", step.msg, result, step.wantResult)
+		}
+	}
+}
+
+func TestMacroInterrupt(t *testing.T) {
+	tests := []struct {
+		name            string
+		send            func(ctx context.Context, registry *command.Registry, macros *macro.Runner) error
+		wantInterrupted bool
+	}{
+		{
+			name: "state changing command",
+			send: func(ctx context.Context, registry *command.Registry, macros *macro.Runner) error {
+				_, _, err := handleMessage(ctx, registry, macros, "toggle_captions")
+				return err
+			},
+			wantInterrupted: true,
+		},
+		{
+			name: "read only command",
+			send: func(ctx context.Context, registry *command.Registry, macros *macro.Runner) error {
+				_, _, err := handleMessage(ctx, registry, macros, "list_scheduled_commands")
+				return err
+			},
+		},
+		{
+			name: "unknown command",
+			send: func(ctx context.Context, registry *command.Registry, macros *macro.Runner) error {
+				_, _, _ = handleMessage(ctx, registry, macros, "self_destruct")
+				return nil
+			},
+		},
+		{
+			name: "scheduled command",
+			send: func(ctx context.Context, registry *command.Registry, macros *macro.Runner) error {
+				_, err := handleCommand(ctx, registry, macros, command.Invocation{Name: "toggle_captions"}, false)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, macros, rec := newTestExecutor(t, youtube)
+
+			movie, err := macros.Command(macro.Config{
+				Name:        "movie_mode",
+				Description: "get ready for a movie",
+				Examples:    []string{"movie mode"},
+				Steps: []macro.StepConfig{
+					{Command: "pause_video"},
+					{Command: "toggle_fullscreen", Delay: "200ms"},
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to create macro: %v", err)
+			}
+
+			if err := registry.Register(movie); err != nil {
+				t.Fatalf("failed to register macro: %v", err)
+			}
+
+			// The macro runs in the background, the executor replies right away.
+			if _, result, err := handleMessage(context.Background(), registry, macros, "movie_mode"); err != nil || result != "started movie_mode" {
+				t.Fatalf("handleMessage(movie_mode) = %q, %v, want %q", result, err, "started movie_mode")
+			}
+
+			// Wait for the macro to pause the video, then send the command while it waits.
+			for deadline := time.Now().Add(time.Second); len(rec.Inputs()) == 0; time.Sleep(5 * time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatalf("macro never ran its first step")
+				}
+			}
+
+			if err := tt.send(context.Background(), registry, macros); err != nil {
+				t.Fatalf("failed to send command: %v", err)
+			}
+
+			macros.Wait()
+
+			if fullscreen := slices.Contains(inputs(rec), "key_tap f"); fullscreen == tt.wantInterrupted {
+				t.Errorf("inputs = %q, want the fullscreen step %t", inputs(rec), !tt.wantInterrupted)
+			}
+		})
+	}
+}
+
+func TestMacroInterruptFromListener(t *testing.T) {
+	registry, macros, rec := newTestExecutor(t, youtube)
+
+	movie, err := macros.Command(macro.Config{
+		Name:        "movie_mode",
+		Description: "get ready for a movie",
+		Examples:    []string{"movie mode"},
+		Steps: []macro.StepConfig{
+			{Command: "pause_video"},
+			{Command: "toggle_fullscreen", Delay: "5s"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create macro: %v", err)
+	}
+
+	if err := registry.Register(movie); err != nil {
+		t.Fatalf("failed to register macro: %v", err)
+	}
+
+	// Find a free port for the server.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	srv := server.NewTCPServer(server.TCPServerConfig{
+		Address:   address,
+		OnMessage: createMessageHandler(&env.Env{}, registry, macros),
+	})
+	if err := srv.Start(ctx); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+
+	client, err := executor.NewClient(executor.ClientConfig{Address: address, Source: "listener"})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	// The listener waits for each reply, so the macro must not block it until its last step.
+	start := time.Now()
+	resp, err := client.SendCommand(ctx, command.Invocation{Name: "movie_mode"})
+	if err != nil {
+		t.Fatalf("SendCommand(movie_mode) failed: %v", err)
+	}
+
+	if elapsed := time.Since(start); resp.Result != "started movie_mode" || elapsed > time.Second {
+		t.Errorf("SendCommand(movie_mode) = %q in %s, want %q right away", resp.Result, elapsed, "started movie_mode")
+	}
+
+	// Wait for the macro to pause the video, then send the command while it waits.
+	for deadline := time.Now().Add(time.Second); len(rec.Inputs()) == 0; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("macro never ran its first step")
+		}
+	}
+
+	if _, err := client.SendCommand(ctx, command.Invocation{Name: "toggle_captions"}); err != nil {
+		t.Fatalf("SendCommand(toggle_captions) failed: %v", err)
+	}
+
+	macros.Wait()
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("macro stopped after %s, want the command to interrupt it", elapsed)
+	}
+
+	want := []string{"key_tap k", "key_tap c"}
+	if got := inputs(rec); !slices.Equal(got, want) {
+		t.Errorf("inputs = %q, want %q", got, want)
+	}
+}
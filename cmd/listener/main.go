@@ -3,41 +3,36 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"runtime"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/nizarmah/jarvis/internal/command"
+	"github.com/nizarmah/jarvis/internal/config"
+	"github.com/nizarmah/jarvis/internal/dedup"
+	"github.com/nizarmah/jarvis/internal/dictation"
 	"github.com/nizarmah/jarvis/internal/env"
 	"github.com/nizarmah/jarvis/internal/executor"
 	"github.com/nizarmah/jarvis/internal/ffmpeg"
+	"github.com/nizarmah/jarvis/internal/hallucination"
+	"github.com/nizarmah/jarvis/internal/intent"
 	"github.com/nizarmah/jarvis/internal/ollama"
+	"github.com/nizarmah/jarvis/internal/timer"
+	"github.com/nizarmah/jarvis/internal/wakeword"
 	"github.com/nizarmah/jarvis/internal/whisper"
 )
 
-var (
-	wakeUpWord = "jarvis"
-)
-
 var (
 	// TranscribePromptTemplate is the prompt used on Whisper.
 	transcribePromptTemplate = ""
-
-	// InterpretPromptTemplate is the prompt used on Ollama.
-	interpretPromptTemplate = fmt.Sprintf(
-		"You are a command interpreter for audio transcripts generated by an AI model called Whisper. "+
-			"Whisper may hallucinate phrases, especially repetitive ones or filler like 'you are a voice assistant'. "+
-			"Your job is to determine if the transcript contains a valid instruction or if it's nonsense. "+
-			"If the input is valid, respond with the closest matching command from this list: %s. "+
-			"If it is a hallucination or unrelated content, respond with 'do_nothing'. "+
-			"Transcript: %%q. "+
-			"Respond with one sentence only: the command from the list or 'do_nothing'.",
-		strings.Join(executor.Commands, " | "),
-	)
 )
 
 func main() {
@@ -54,29 +49,171 @@ func main() {
 	)
 	defer cancel()
 
+	// Initialize the command registry.
+	registry, err := command.NewDefaultRegistry()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the kitchen timers, handled by the listener rather than the executor.
+	alarm, err := timer.NewAlarm(timer.AlarmConfig{
+		Backend:  e.TimerAlarmBackend,
+		Binary:   e.TimerAlarmBinary,
+		Debug:    e.TimerDebug,
+		Duration: e.TimerRingDuration,
+		Output:   e.TimerAlarmOutput,
+		Sound:    e.TimerAlarmSound,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	timers, err := timer.New(timer.Config{
+		Alarm:        alarm,
+		Announcer:    e.TimerAnnouncer,
+		Debug:        e.TimerDebug,
+		RingDuration: e.TimerRingDuration,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Start the timers in context so they are auto-stopped.
+	timers.Start(ctx)
+
+	// Register the listener commands, so the prompt lists them.
+	local, err := createLocalRegistry(timers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, cmd := range local.Commands() {
+		if err := registry.Register(cmd); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Register the user-defined commands, so the prompt lists them.
+	cfg, err := config.Load(e.ConfigFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := cfg.Register(registry, e.CommandDebug); err != nil {
+		log.Fatal(err)
+	}
+
+	// Ensure the few-shot examples match the commands.
+	if err := intent.ValidateExamples(registry, intent.Examples); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize the executor client.
 	executor, err := executor.NewClient(executor.ClientConfig{
-		Address: e.ExecutorAddress,
-		Debug:   e.ExecutorDebug,
+		Address:    e.ExecutorAddress,
+		Debug:      e.ExecutorDebug,
+		Retries:    e.ExecutorRetries,
+		RetryDelay: e.ExecutorRetryDelay,
+		Source:     "listener",
 	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// Initialize the ollama client.
-	interpreter := ollama.NewClient(ollama.ClientConfig{
-		Debug: e.OllamaDebug,
-		Model: e.OllamaModel,
-		URL:   e.OllamaURL,
+	interpreter, err := ollama.NewClient(ollama.ClientConfig{
+		Debug:     e.OllamaDebug,
+		KeepAlive: e.OllamaKeepAlive,
+		Model:     e.OllamaModel,
+		Options: ollama.Options{
+			Temperature: e.OllamaTemperature,
+			NumPredict:  e.OllamaNumPredict,
+			Stop:        e.OllamaStop,
+			Seed:        e.OllamaSeed,
+		},
+		Retries:    e.OllamaRetries,
+		RetryDelay: e.OllamaRetryDelay,
+		Timeout:    e.OllamaTimeout,
+		URL:        e.OllamaURL,
 	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Initialize the whisper client.
-	transcriber, err := whisper.NewClient(ctx, whisper.ClientConfig{
+	// Ensure the model was pulled, then load it so the first command isn't slow.
+	// Ollama may start after the listener, so only a missing model is fatal.
+	if err := interpreter.CheckModel(ctx); err != nil {
+		if errors.Is(err, ollama.ErrModelNotFound) {
+			log.Fatal(err)
+		}
+
+		log.Println(fmt.Sprintf("failed to check ollama model: %s", describeOllamaError(err)))
+	} else {
+		log.Println(fmt.Sprintf("Warming up %s...", e.OllamaModel))
+		if err := interpreter.WarmUp(ctx); err != nil {
+			log.Println(err)
+		}
+	}
+
+	// Initialize the whisper transcriber.
+	transcriber, err := whisper.New(ctx, whisper.Config{
+		Backend:   e.WhisperBackend,
+		Binary:    e.WhisperBinary,
+		URL:       e.WhisperURL,
+		APIKey:    e.WhisperAPIKey,
 		Debug:     e.WhisperDebug,
 		Model:     e.WhisperModel,
 		Language:  e.WhisperLanguage,
 		OutputDir: e.WhisperOutputDir,
 		Prompt:    transcribePromptTemplate,
+		Timeout:   e.WhisperTimeout,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the hallucination filter.
+	filter, err := hallucination.NewFilter(hallucination.Config{
+		Blocklist:           e.HallucinationBlocklist,
+		Debug:               e.HallucinationDebug,
+		MaxRepeats:          e.HallucinationMaxRepeats,
+		MaxNoSpeechProb:     e.HallucinationMaxNoSpeechProb,
+		MinAvgLogprob:       e.HallucinationMinAvgLogprob,
+		MaxCompressionRatio: e.HallucinationMaxCompressionRatio,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the wake word detector.
+	detector, err := wakeword.NewDetector(wakeword.Config{
+		Phrases:         e.WakeWordPhrases,
+		Aliases:         e.WakeWordAliases,
+		AlwaysListening: e.WakeWordAlwaysListening,
+		Debug:           e.WakeWordDebug,
+		MaxDistance:     e.WakeWordMaxDistance,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the deduplicator.
+	deduplicator, err := dedup.NewDeduplicator(dedup.Config{
+		Debug:      e.DedupDebug,
+		Similarity: e.DedupSimilarity,
+		Window:     e.DedupWindow,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize the dictation mode.
+	dictationMode, err := dictation.NewMode(dictation.Config{
+		Debug:        e.DictationDebug,
+		StartPhrases: e.DictationStartPhrases,
+		EndPhrases:   e.DictationEndPhrases,
+		UndoPhrases:  e.DictationUndoPhrases,
+		IdleTimeout:  e.DictationIdleTimeout,
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -100,7 +237,7 @@ func main() {
 		Debug:      e.CombinerDebug,
 		InputDir:   e.RecorderOutputDir,
 		OutputDir:  e.CombinerOutputDir,
-		OnCombined: createAudioProcessor(e, transcriber, interpreter, executor),
+		OnCombined: createAudioProcessor(e, registry, local, transcriber, filter, detector, deduplicator, dictationMode, interpreter, executor),
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -118,55 +255,102 @@ func main() {
 	}
 
 	log.Println("Jarvis is listening...")
-	log.Println("To use Jarvis, say 'Jarvis, <command>!'")
+	if e.WakeWordAlwaysListening {
+		log.Println("To use Jarvis, say '<command>!'")
+	} else {
+		log.Println(fmt.Sprintf("To use Jarvis, say '%s, <command>!'", e.WakeWordPhrases[0]))
+	}
 
 	log.Println("Available commands:")
-	log.Println("\t- pause the YouTube video")
-	log.Println("\t- play the YouTube video")
+	for _, line := range registry.Help() {
+		log.Println(fmt.Sprintf("\t- %s", line))
+	}
 
 	log.Println("Press Ctrl+C to stop.")
 
 	// Wait for Ctrl+C or kill from context.
 	<-ctx.Done()
+	log.Println(fmt.Sprintf("hallucination filter stats: %s", filter.Stats()))
 	log.Println("Context cancelled — exiting.")
 }
 
 // CreateAudioProcessor creates a function that transcribes the audio file and extracts the command.
 func createAudioProcessor(
 	e *env.Env,
-	transcriber *whisper.Client,
+	registry *command.Registry,
+	local *command.Registry,
+	transcriber whisper.Transcriber,
+	filter *hallucination.Filter,
+	detector *wakeword.Detector,
+	deduplicator *dedup.Deduplicator,
+	dictationMode *dictation.Mode,
 	interpreter *ollama.Client,
 	executor *executor.Client,
 ) ffmpeg.OnCombinedFunc {
+	// Build the messages and the schema once, the commands don't change at runtime.
+	messages := buildInterpretMessages(registry)
+	schema := intent.Schema(registry.Commands())
+
 	return func(ctx context.Context, filePath string) error {
-		// Transcribe the audio file.
-		transcript, err := transcribeAudio(ctx, transcriber, filePath)
+		// Get when the audio was recorded, to match overlapping windows.
+		at, err := ffmpeg.CombinedTime(filePath)
 		if err != nil {
-			return fmt.Errorf("failed to transcribe audio: %w", err)
+			at = time.Now()
 		}
 
-		// Ignore empty or hallucinated transcripts.
-		if transcript == "" || transcript == "you" {
+		// Transcribe the audio file.
+		// Report failures without stopping the combiner, whisper may recover by the next chunk.
+		transcript, err := transcribeAudio(ctx, transcriber, filePath)
+		if err != nil {
+			log.Println(err)
 			return nil
 		}
 
 		if e.AudioProcessorDebug {
-			log.Println(fmt.Sprintf("transcript: %s", transcript))
+			log.Println(fmt.Sprintf(
+				"transcript: %s (avg logprob: %.2f, no speech prob: %.2f, compression ratio: %.2f)",
+				transcript.Text,
+				transcript.AvgLogprob(),
+				transcript.MaxNoSpeechProb(),
+				transcript.MaxCompressionRatio(),
+			))
 		}
 
-		// Check if the transcript has the wake up word.
-		// if !hasWakeUpWord(transcript) {
-		// 	return nil
-		// }
+		// Type the transcript in dictation mode, without the wake word or interpretation.
+		// Dictation only drops the silence, the blocked phrases are likely dictated, eg. `thank you`.
+		if dictationMode.Active(at) {
+			if _, ok := filter.CheckDictation(transcript); !ok {
+				return nil
+			}
 
-		// Extract the command from the transcript.
-		cmd, err := interpretCommand(ctx, interpreter, transcript)
-		if err != nil {
-			if e.AudioProcessorDebug {
-				log.Println(fmt.Sprintf("failed to extract command: %s", err))
+			if cmd, ok := dictationMode.Transcript(transcript.Text, at); ok {
+				executeCommand(ctx, e, local, executor, cmd)
 			}
 
-			return fmt.Errorf("failed to extract command: %w", err)
+			return nil
+		}
+
+		// Drop hallucinations before they cost an LLM round trip.
+		if _, ok := filter.Check(transcript); !ok {
+			return nil
+		}
+
+		// Check if the transcript addresses Jarvis, and strip the wake word.
+		instruction, ok := detector.Detect(transcript.Text)
+		if !ok || instruction == "" {
+			return nil
+		}
+
+		// Skip the instruction if it was already executed from the previous window.
+		if deduplicator.SeenUtterance(instruction, at) {
+			return nil
+		}
+
+		// Handle the dictation instructions, eg. `type on my way`, without interpretation.
+		cmd, ok := dictationMode.Instruction(instruction, at)
+		if !ok {
+			// Extract the command from the instruction.
+			cmd = interpretCommand(ctx, e, registry, interpreter, messages, schema, instruction)
 		}
 
 		if e.AudioProcessorDebug {
@@ -174,84 +358,263 @@ func createAudioProcessor(
 		}
 
 		// If the command is empty, do nothing.
-		if cmd == "" {
+		if cmd.Name == "" {
+			return nil
+		}
+
+		// Skip the command if it was just executed.
+		if deduplicator.SeenCommand(cmd, at) {
 			return nil
 		}
 
 		// Execute the command.
-		if err := executor.SendCommand(ctx, cmd); err != nil {
-			if e.AudioProcessorDebug {
-				log.Println(fmt.Sprintf("failed to send command to executor: %s", err))
+		if executeCommand(ctx, e, local, executor, cmd) {
+			deduplicator.Record(instruction, cmd, at)
+		}
+
+		return nil
+	}
+}
+
+// CreateLocalRegistry creates the registry of the commands handled by the listener, eg. timers.
+func createLocalRegistry(timers *timer.Timers) (*command.Registry, error) {
+	local, err := command.NewRegistry(command.TimerBuiltins...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local registry: %w", err)
+	}
+
+	handlers := map[string]command.HandlerFunc{
+		"set_timer": func(_ context.Context, args command.Values) (string, error) {
+			t, err := timers.Set(args.Duration("duration"), args.Text("name"))
+			if err != nil {
+				return "", fmt.Errorf("failed to set timer: %w", err)
+			}
+
+			return fmt.Sprintf("%s set", t.Label()), nil
+		},
+		"timer_status": func(ctx context.Context, args command.Values) (string, error) {
+			status := timerStatus(timers.List(args.Text("name")))
+			timers.Announce(ctx, status)
+
+			return status, nil
+		},
+		"cancel_timer": func(_ context.Context, args command.Values) (string, error) {
+			cancelled := timers.Cancel(args.Text("name"))
+			if len(cancelled) == 0 {
+				return "no timers to cancel", nil
 			}
 
-			return fmt.Errorf("failed to send command to executor: %w", err)
+			return fmt.Sprintf("cancelled %d timers", len(cancelled)), nil
+		},
+		"stop_alarm": func(_ context.Context, _ command.Values) (string, error) {
+			if !timers.StopAlarm() {
+				return "no alarm ringing", nil
+			}
+
+			return "stopped the alarm", nil
+		},
+	}
+
+	for name, handler := range handlers {
+		if err := local.Handle(name, handler); err != nil {
+			return nil, fmt.Errorf("failed to bind handler: %w", err)
 		}
+	}
 
-		return nil
+	if err := local.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate local registry: %w", err)
+	}
+
+	return local, nil
+}
+
+// TimerStatus tells how long is left on the timers, eg. `8 minutes left on the pasta timer`.
+func timerStatus(timers []timer.Timer) string {
+	if len(timers) == 0 {
+		return "no timers running"
+	}
+
+	now := time.Now()
+
+	parts := make([]string, len(timers))
+	for i, t := range timers {
+		parts[i] = fmt.Sprintf("%s left on the %s", timer.FormatDuration(t.End.Sub(now)), t.Label())
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// ExecuteCommand runs the listener commands, or sends the command to the executor, and reports whether it was executed.
+func executeCommand(ctx context.Context, e *env.Env, local *command.Registry, executor *executor.Client, cmd command.Invocation) bool {
+	if _, ok := local.Lookup(cmd.Name); ok {
+		result, err := local.Dispatch(ctx, cmd)
+		if err != nil {
+			log.Println(fmt.Sprintf("failed to execute command %q: %s", cmd, err))
+			return false
+		}
+
+		if e.AudioProcessorDebug {
+			log.Println(fmt.Sprintf("executed command %q: %s", cmd, result))
+		}
+
+		return true
+	}
+
+	resp, err := executor.SendCommand(ctx, cmd)
+	if err != nil {
+		// Report failures without stopping the listener, the next command may succeed.
+		log.Println(fmt.Sprintf("failed to execute command %q: %s", cmd, describeExecutorError(err)))
+		return false
+	}
+
+	if e.AudioProcessorDebug {
+		log.Println(fmt.Sprintf("executed command %q in %dms: %s", cmd, resp.LatencyMS, resp.Result))
 	}
+
+	return true
 }
 
-// TranscribeAudio transcribes the audio file.
+// TranscribeAudio transcribes the audio file, normalizing the text of the transcript.
 func transcribeAudio(
 	ctx context.Context,
-	transcriber *whisper.Client,
+	transcriber whisper.Transcriber,
 	filePath string,
-) (string, error) {
+) (whisper.Transcript, error) {
 	// Transcribe the audio file.
-	untrimmed, err := transcriber.Transcribe(ctx, filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to transcribe audio: %w", err)
+	transcript, err := transcriber.Transcribe(ctx, filePath)
+
+	// Clean up the audio file, also when it failed so the failures don't pile up.
+	if removeErr := os.Remove(filePath); removeErr != nil {
+		return whisper.Transcript{}, fmt.Errorf("failed to cleanup audio file: %w", removeErr)
 	}
 
-	// Clean up the audio file.
-	if err := os.Remove(filePath); err != nil {
-		return "", fmt.Errorf("failed to cleanup audio file: %w", err)
+	if err != nil {
+		return whisper.Transcript{}, fmt.Errorf("failed to transcribe audio: %w", err)
 	}
 
 	// Cleanup punctuation.
-	cleaned := strings.ReplaceAll(untrimmed, ".", "")
+	cleaned := strings.ReplaceAll(transcript.Text, ".", "")
 
 	// Trim the transcript.
 	trimmed := strings.TrimSpace(cleaned)
 
-	return strings.ToLower(trimmed), nil
+	transcript.Text = strings.ToLower(trimmed)
+
+	return transcript, nil
+}
+
+// DescribeExecutorError explains the executor error to the user.
+func describeExecutorError(err error) string {
+	var cmdErr *executor.CommandError
+	switch {
+	case errors.As(err, &cmdErr):
+		return fmt.Sprintf("executor rejected it: %s", cmdErr.Message)
+
+	case errors.Is(err, executor.ErrUnavailable):
+		return fmt.Sprintf("executor is unreachable, is it running? %s", err)
+
+	case errors.Is(err, executor.ErrNoResponse):
+		return fmt.Sprintf("executor did not respond, it may or may not have executed it: %s", err)
+
+	default:
+		return err.Error()
+	}
+}
+
+// DescribeOllamaError explains the ollama error to the user.
+func describeOllamaError(err error) string {
+	switch {
+	case errors.Is(err, ollama.ErrUnavailable):
+		return fmt.Sprintf("ollama is unreachable, is it running? %s", err)
+
+	case errors.Is(err, ollama.ErrModelNotFound):
+		return fmt.Sprintf("ollama does not have the model, was it pulled? %s", err)
+
+	case errors.Is(err, ollama.ErrTimeout):
+		return fmt.Sprintf("ollama is too slow, is the model too large? %s", err)
+
+	case errors.Is(err, ollama.ErrInvalidResponse):
+		return fmt.Sprintf("ollama replied with an invalid command: %s", err)
+
+	case errors.Is(err, ollama.ErrRequestFailed):
+		return fmt.Sprintf("ollama failed the request, check its logs: %s", err)
+
+	default:
+		return err.Error()
+	}
 }
 
-// HasWakeUpWord checks if the wake up word is in the transcript.
-func hasWakeUpWord(transcript string) bool {
-	return strings.Contains(transcript, wakeUpWord)
+// BuildInterpretMessages builds the system prompt listing the registered commands, followed by the few-shot examples.
+func buildInterpretMessages(registry *command.Registry) []ollama.Message {
+	messages := []ollama.Message{{
+		Role:    ollama.RoleSystem,
+		Content: intent.SystemPrompt(registry.Commands()),
+	}}
+
+	for _, ex := range intent.Examples {
+		// The examples are plain structs, encoding them never fails.
+		reply, _ := json.Marshal(ex.Intent)
+
+		messages = append(messages,
+			ollama.Message{Role: ollama.RoleUser, Content: intent.UserPrompt(ex.Transcript)},
+			ollama.Message{Role: ollama.RoleAssistant, Content: string(reply)},
+		)
+	}
+
+	return messages
 }
 
 // InterpretCommand interprets the command from the transcript.
+// It reports the failures and returns an empty command, the next instruction may succeed.
 func interpretCommand(
 	ctx context.Context,
+	e *env.Env,
+	registry *command.Registry,
 	interpreter *ollama.Client,
+	messages []ollama.Message,
+	schema map[string]any,
 	transcript string,
-) (string, error) {
-	// Build a prompt to instruct LLM.
-	prompt := fmt.Sprintf(interpretPromptTemplate, transcript)
-
-	// Prompt the LLM.
-	response, err := interpreter.Prompt(ctx, prompt)
-	if err != nil {
-		log.Println(fmt.Sprintf("failed to prompt LLM: %s", err))
+) command.Invocation {
+	// Append the transcript to the instructions, without growing the shared messages.
+	messages = append(slices.Clip(messages), ollama.Message{
+		Role:    ollama.RoleUser,
+		Content: intent.UserPrompt(transcript),
+	})
 
-		// Ignore the error if the context was cancelled.
-		if errors.Is(err, context.DeadlineExceeded) {
-			return "", nil
+	// Stream the reply of the LLM, constrained to the commands, until the rest of the reply can't change the command.
+	var (
+		in    intent.Intent
+		early bool
+	)
+	reply, err := interpreter.ChatStream(ctx, messages, schema, func(_ string, reply string) bool {
+		in, early = intent.Early(reply, registry)
+		return early
+	})
+	if err == nil && !early {
+		if err = json.Unmarshal([]byte(reply), &in); err != nil {
+			err = fmt.Errorf("%w: %w: %q", ollama.ErrInvalidResponse, err, reply)
 		}
+	}
 
-		return "", fmt.Errorf("failed to prompt LLM: %w", err)
+	if err != nil {
+		// Ollama may be restarted or the model pulled meanwhile, only the startup check is fatal.
+		log.Println(fmt.Sprintf("failed to prompt LLM: %s", describeOllamaError(err)))
+		return command.Invocation{}
 	}
 
-	log.Println(fmt.Sprintf("response: %s", response))
+	if e.IntentDebug {
+		log.Println(fmt.Sprintf(
+			"intent: %s %v (confidence: %.2f, early: %t, prompt v%d)",
+			in.Command, in.Args, in.Confidence, early, intent.PromptVersion,
+		))
+	}
 
-	// Search for the command in the response.
-	for _, command := range executor.Commands {
-		if strings.Contains(response, command) {
-			return command, nil
-		}
+	// Validate the intent before sending it to the executor.
+	inv, err := in.Invocation(registry, e.IntentMinConfidence)
+	if err != nil {
+		log.Println(fmt.Sprintf("rejected command %q: %s", in.Command, err))
+		return command.Invocation{}
 	}
 
-	return "", nil
+	return inv
 }
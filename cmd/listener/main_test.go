@@ -0,0 +1,519 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+	"github.com/nizarmah/jarvis/internal/dedup"
+	"github.com/nizarmah/jarvis/internal/dictation"
+	"github.com/nizarmah/jarvis/internal/env"
+	"github.com/nizarmah/jarvis/internal/executor"
+	"github.com/nizarmah/jarvis/internal/hallucination"
+	"github.com/nizarmah/jarvis/internal/intent"
+	"github.com/nizarmah/jarvis/internal/ollama"
+	"github.com/nizarmah/jarvis/internal/protocol"
+	"github.com/nizarmah/jarvis/internal/wakeword"
+	"github.com/nizarmah/jarvis/internal/whisper"
+)
+
+// newTestRegistry creates the registry the listener prompts with, without user-defined commands.
+func newTestRegistry(t *testing.T) *command.Registry {
+	t.Helper()
+
+	registry, err := command.NewDefaultRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	for _, cmd := range command.TimerBuiltins {
+		if err := registry.Register(cmd); err != nil {
+			t.Fatalf("failed to register %q: %v", cmd.Name, err)
+		}
+	}
+
+	return registry
+}
+
+// testSchema returns the schema of the registry as decoded JSON, like the model reads it.
+func testSchema(t *testing.T, registry *command.Registry) any {
+	t.Helper()
+
+	encoded, err := json.Marshal(intent.Schema(registry.Commands()))
+	if err != nil {
+		t.Fatalf("failed to encode schema: %v", err)
+	}
+
+	var schema any
+	if err := json.Unmarshal(encoded, &schema); err != nil {
+		t.Fatalf("failed to decode schema: %v", err)
+	}
+
+	return schema
+}
+
+// matchesSchema checks the value against the subset of JSON schema used by the intents.
+func matchesSchema(schema, value any) bool {
+	s, _ := schema.(map[string]any)
+
+	if variants, ok := s["anyOf"].([]any); ok {
+		return slices.ContainsFunc(variants, func(v any) bool { return matchesSchema(v, value) })
+	}
+
+	if enum, ok := s["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return false
+	}
+
+	switch s["type"] {
+	case "string":
+		_, ok := value.(string)
+		return ok
+
+	case "number":
+		n, ok := value.(float64)
+		if minimum, set := s["minimum"].(float64); set && n < minimum {
+			return false
+		}
+		if maximum, set := s["maximum"].(float64); set && n > maximum {
+			return false
+		}
+		return ok
+
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		required, _ := s["required"].([]any)
+		for _, key := range required {
+			if _, ok := obj[key.(string)]; !ok {
+				return false
+			}
+		}
+
+		props, _ := s["properties"].(map[string]any)
+		for key, v := range obj {
+			prop, ok := props[key]
+			if !ok && s["additionalProperties"] == false {
+				return false
+			}
+
+			if ok && !matchesSchema(prop, v) {
+				return false
+			}
+		}
+
+		return true
+
+	default:
+		return false
+	}
+}
+
+func TestBuildInterpretMessages(t *testing.T) {
+	registry := newTestRegistry(t)
+	schema := testSchema(t, registry)
+
+	if err := intent.ValidateExamples(registry, intent.Examples); err != nil {
+		t.Fatalf("ValidateExamples() failed: %v", err)
+	}
+
+	messages := buildInterpretMessages(registry)
+	if want := 1 + 2*len(intent.Examples); len(messages) != want {
+		t.Fatalf("buildInterpretMessages() = %d messages, want %d", len(messages), want)
+	}
+
+	if system := messages[0]; system.Role != ollama.RoleSystem || !strings.Contains(system.Content, "set_timer duration=<duration>") {
+		t.Errorf("system message = %+v, want the system prompt listing the commands", system)
+	}
+
+	for i, ex := range intent.Examples {
+		user, assistant := messages[1+2*i], messages[2+2*i]
+
+		if user.Role != ollama.RoleUser || user.Content != intent.UserPrompt(ex.Transcript) {
+			t.Errorf("example %q: user message = %+v", ex.Transcript, user)
+		}
+
+		if assistant.Role != ollama.RoleAssistant {
+			t.Errorf("example %q: assistant message role = %q", ex.Transcript, assistant.Role)
+		}
+
+		var reply any
+		if err := json.Unmarshal([]byte(assistant.Content), &reply); err != nil {
+			t.Fatalf("example %q: invalid reply %q: %v", ex.Transcript, assistant.Content, err)
+		}
+
+		if !matchesSchema(schema, reply) {
+			t.Errorf("example %q: reply %s does not match the schema", ex.Transcript, assistant.Content)
+		}
+
+		var in intent.Intent
+		if err := json.Unmarshal([]byte(assistant.Content), &in); err != nil {
+			t.Fatalf("example %q: invalid intent %q: %v", ex.Transcript, assistant.Content, err)
+		}
+
+		if _, err := in.Invocation(registry, 0); err != nil {
+			t.Errorf("example %q: reply %s is not a valid command: %v", ex.Transcript, assistant.Content, err)
+		}
+	}
+}
+
+func TestInvalidExamples(t *testing.T) {
+	registry := newTestRegistry(t)
+	schema := testSchema(t, registry)
+
+	tests := []struct {
+		name   string
+		intent intent.Intent
+	}{
+		{name: "unknown command", intent: intent.Intent{Command: "self_destruct", Confidence: 0.9, Args: map[string]string{}}},
+		{name: "unknown argument", intent: intent.Intent{Command: "pause_video", Confidence: 0.9, Args: map[string]string{"player": "vlc"}}},
+		{name: "missing argument", intent: intent.Intent{Command: "set_volume", Confidence: 0.9, Args: map[string]string{}}},
+		{name: "confidence out of range", intent: intent.Intent{Command: "pause_video", Confidence: 1.5, Args: map[string]string{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			examples := []intent.Example{{Transcript: tt.name, Intent: tt.intent}}
+			if err := intent.ValidateExamples(registry, examples); err == nil {
+				t.Errorf("ValidateExamples(%+v) succeeded, want an error", tt.intent)
+			}
+
+			encoded, err := json.Marshal(tt.intent)
+			if err != nil {
+				t.Fatalf("failed to encode intent: %v", err)
+			}
+
+			var reply any
+			if err := json.Unmarshal(encoded, &reply); err != nil {
+				t.Fatalf("failed to decode intent: %v", err)
+			}
+
+			if matchesSchema(schema, reply) {
+				t.Errorf("intent %+v matches the schema, want a mismatch", tt.intent)
+			}
+		})
+	}
+}
+
+// transcriberFunc transcribes with a function, to fake whisper.
+type transcriberFunc func(ctx context.Context, filePath string) (whisper.Transcript, error)
+
+func (f transcriberFunc) Transcribe(ctx context.Context, filePath string) (whisper.Transcript, error) {
+	return f(ctx, filePath)
+}
+
+// transcripts returns a transcriber that replies with the texts in order, as confident speech.
+func transcripts(texts ...string) whisper.Transcriber {
+	var (
+		mu   sync.Mutex
+		next int
+	)
+
+	return transcriberFunc(func(_ context.Context, _ string) (whisper.Transcript, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		text := texts[next%len(texts)]
+		next++
+
+		return whisper.Transcript{
+			Text:     text,
+			Segments: []whisper.Segment{{End: 2, Text: text, AvgLogprob: -0.2, NoSpeechProb: 0.05, CompressionRatio: 1.1}},
+		}, nil
+	})
+}
+
+// fakeExecutor records the commands the listener sends to the executor.
+type fakeExecutor struct {
+	address string
+
+	mu       sync.Mutex
+	commands []string
+}
+
+// newFakeExecutor serves the executor protocol on a free port, succeeding every command.
+func newFakeExecutor(t *testing.T) *fakeExecutor {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeExecutor{address: listener.Addr().String()}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go f.handle(conn)
+		}
+	}()
+
+	return f
+}
+
+// handle answers a single request, the healthcheck closes the connection without one.
+func (f *fakeExecutor) handle(conn net.Conn) {
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	req, err := protocol.DecodeRequest(line)
+	if err == nil {
+		f.mu.Lock()
+		f.commands = append(f.commands, req.Invocation().String())
+		f.mu.Unlock()
+	}
+
+	reply, _ := protocol.Encode(protocol.NewResponse(req.ID, "", err, 0))
+	_, _ = conn.Write(reply)
+}
+
+// received returns the commands received so far.
+func (f *fakeExecutor) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return slices.Clone(f.commands)
+}
+
+// testAudioProcessor runs the audio processor of the listener on fake audio files.
+type testAudioProcessor struct {
+	process func(ctx context.Context, filePath string) error
+	dir     string
+	at      time.Time
+}
+
+// newTestAudioProcessor creates the audio processor with the example.env settings.
+func newTestAudioProcessor(t *testing.T, transcriber whisper.Transcriber, ollamaURL string, exec *fakeExecutor) *testAudioProcessor {
+	t.Helper()
+
+	registry := newTestRegistry(t)
+
+	local, err := command.NewRegistry()
+	if err != nil {
+		t.Fatalf("failed to create local registry: %v", err)
+	}
+
+	filter, err := hallucination.NewFilter(hallucination.Config{
+		MaxRepeats:          3,
+		MaxNoSpeechProb:     0.8,
+		MinAvgLogprob:       -1.0,
+		MaxCompressionRatio: 2.4,
+	})
+	if err != nil {
+		t.Fatalf("failed to create filter: %v", err)
+	}
+
+	detector, err := wakeword.NewDetector(wakeword.Config{
+		Phrases:     []string{"jarvis", "hey jarvis"},
+		MaxDistance: 1,
+	})
+	if err != nil {
+		t.Fatalf("failed to create detector: %v", err)
+	}
+
+	deduplicator, err := dedup.NewDeduplicator(dedup.Config{Similarity: 0.8, Window: 3 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to create deduplicator: %v", err)
+	}
+
+	dictationMode, err := dictation.NewMode(dictation.Config{
+		StartPhrases: []string{"start dictation"},
+		EndPhrases:   []string{"stop dictation"},
+		UndoPhrases:  []string{"scratch that"},
+		IdleTimeout:  30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to create dictation mode: %v", err)
+	}
+
+	interpreter, err := ollama.NewClient(ollama.ClientConfig{
+		Model:   "llama3",
+		Timeout: time.Second,
+		URL:     ollamaURL,
+	})
+	if err != nil {
+		t.Fatalf("failed to create ollama client: %v", err)
+	}
+
+	client, err := executor.NewClient(executor.ClientConfig{
+		Address: exec.address,
+		Source:  "listener",
+	})
+	if err != nil {
+		t.Fatalf("failed to create executor client: %v", err)
+	}
+
+	e := &env.Env{IntentMinConfidence: 0.5}
+
+	return &testAudioProcessor{
+		process: createAudioProcessor(e, registry, local, transcriber, filter, detector, deduplicator, dictationMode, interpreter, client),
+		dir:     t.TempDir(),
+		at:      time.Now(),
+	}
+}
+
+// next processes the next combined audio file, recorded a chunk after the previous one.
+func (p *testAudioProcessor) next(t *testing.T) error {
+	t.Helper()
+
+	p.at = p.at.Add(2 * time.Second)
+
+	path := filepath.Join(p.dir, fmt.Sprintf("combined_%d.wav", p.at.UnixNano()))
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatalf("failed to write audio: %v", err)
+	}
+
+	return p.process(context.Background(), path)
+}
+
+func TestAudioProcessorDictatesBlockedPhrases(t *testing.T) {
+	exec := newFakeExecutor(t)
+	transcriber := transcripts(
+		"Jarvis, start dictation.",
+		"Thank you.",
+		"Okay.",
+		"Bye.",
+		"Stop dictation.",
+		"Thank you.",
+	)
+
+	p := newTestAudioProcessor(t, transcriber, "http://127.0.0.1:1", exec)
+	for range 6 {
+		if err := p.next(t); err != nil {
+			t.Fatalf("process() failed: %v", err)
+		}
+	}
+
+	// The blocked phrases are typed while dictating, and dropped after.
+	want := []string{`type_text text="thank you"`, "type_text text=okay", "type_text text=bye"}
+	if got := exec.received(); !slices.Equal(got, want) {
+		t.Errorf("executor received %q, want %q", got, want)
+	}
+}
+
+func TestAudioProcessorDictationDropsSilence(t *testing.T) {
+	exec := newFakeExecutor(t)
+
+	next := 0
+	transcriber := transcriberFunc(func(_ context.Context, _ string) (whisper.Transcript, error) {
+		next++
+		if next == 1 {
+			return whisper.Transcript{Text: "Jarvis, start dictation."}, nil
+		}
+
+		// Whisper hears thanks in the silence.
+		return whisper.Transcript{
+			Text:     "Thanks.",
+			Segments: []whisper.Segment{{End: 2, Text: "Thanks.", AvgLogprob: -1.2, NoSpeechProb: 0.9}},
+		}, nil
+	})
+
+	p := newTestAudioProcessor(t, transcriber, "http://127.0.0.1:1", exec)
+	for range 2 {
+		if err := p.next(t); err != nil {
+			t.Fatalf("process() failed: %v", err)
+		}
+	}
+
+	if got := exec.received(); len(got) != 0 {
+		t.Errorf("executor received %q, want nothing", got)
+	}
+}
+
+func TestAudioProcessorKeepsGoingOnErrors(t *testing.T) {
+	// ollamaError serves the error on every request, like a running ollama.
+	ollamaError := func(t *testing.T, status int, message string) string {
+		t.Helper()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+		}))
+		t.Cleanup(server.Close)
+
+		return server.URL
+	}
+
+	tests := []struct {
+		name        string
+		transcriber whisper.Transcriber
+		ollamaURL   func(t *testing.T) string
+	}{
+		{
+			name:        "ollama unavailable",
+			transcriber: transcripts("Jarvis, pause the video."),
+			ollamaURL:   func(t *testing.T) string { return "http://127.0.0.1:1" },
+		},
+		{
+			name:        "model not found",
+			transcriber: transcripts("Jarvis, pause the video."),
+			ollamaURL: func(t *testing.T) string {
+				return ollamaError(t, http.StatusNotFound, `model "llama3" not found, try pulling it first`)
+			},
+		},
+		{
+			name:        "request failed",
+			transcriber: transcripts("Jarvis, pause the video."),
+			ollamaURL: func(t *testing.T) string {
+				return ollamaError(t, http.StatusInternalServerError, "model runner has unexpectedly stopped")
+			},
+		},
+		{
+			name: "transcription failed",
+			transcriber: transcriberFunc(func(_ context.Context, _ string) (whisper.Transcript, error) {
+				return whisper.Transcript{}, errors.New("whisper worker exited: EOF")
+			}),
+			ollamaURL: func(t *testing.T) string { return "http://127.0.0.1:1" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := newFakeExecutor(t)
+			p := newTestAudioProcessor(t, tt.transcriber, tt.ollamaURL(t), exec)
+
+			// An error would stop the combiner for good, the next chunks may succeed.
+			for range 3 {
+				if err := p.next(t); err != nil {
+					t.Fatalf("process() error = %v, want the failure logged", err)
+				}
+			}
+
+			if got := exec.received(); len(got) != 0 {
+				t.Errorf("executor received %q, want nothing", got)
+			}
+
+			// The failed audio files are cleaned up too.
+			if files, _ := os.ReadDir(p.dir); len(files) != 0 {
+				t.Errorf("%d audio files left, want none", len(files))
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+#!/usr/bin/env python3
+"""Persistent whisper worker for jarvis.
+
+Loads the model once and transcribes audio buffers over newline-delimited
+JSON on stdin/stdout, so each transcription skips reloading the model.
+
+Requests:
+    {"id": "1", "audio": "<base64 16 kHz mono 16-bit wav>", "language": "English", "prompt": ""}
+    {"id": "2", "ping": true}
+
+Responses:
+    {"ready": true}                        once the model is loaded
+    {"id": "1", "result": {...}}           the whisper result, without tokens
+    {"id": "1", "error": "..."}            if the transcription failed
+    {"id": "2", "pong": true}
+"""
+
+import argparse
+import base64
+import io
+import json
+import sys
+import wave
+
+import numpy as np
+import whisper
+
+
+def load_wav(data):
+    """Decodes a 16 kHz mono 16-bit wav into the float32 samples whisper expects."""
+    with wave.open(io.BytesIO(data)) as w:
+        if w.getsampwidth() != 2 or w.getnchannels() != 1 or w.getframerate() != 16000:
+            raise ValueError("expected a 16 kHz mono 16-bit wav")
+
+        frames = w.readframes(w.getnframes())
+
+    return np.frombuffer(frames, np.int16).astype(np.float32) / 32768.0
+
+
+def reply(out, message):
+    """Writes the message as a single JSON line."""
+    out.write(json.dumps(message) + "\n")
+    out.flush()
+
+
+def main():
+    parser = argparse.ArgumentParser(description=__doc__)
+    parser.add_argument("--model", required=True, help="name of the whisper model, eg. tiny.en")
+    args = parser.parse_args()
+
+    # Keep stdout for the protocol, whisper and torch may print on it.
+    out = sys.stdout
+    sys.stdout = sys.stderr
+
+    model = whisper.load_model(args.model)
+    reply(out, {"ready": True})
+
+    for line in sys.stdin:
+        line = line.strip()
+        if not line:
+            continue
+
+        try:
+            request = json.loads(line)
+        except ValueError as e:
+            reply(out, {"error": f"invalid request: {e}"})
+            continue
+
+        request_id = request.get("id")
+
+        if request.get("ping"):
+            reply(out, {"id": request_id, "pong": True})
+            continue
+
+        try:
+            audio = load_wav(base64.b64decode(request["audio"]))
+            result = model.transcribe(
+                audio,
+                language=request.get("language") or None,
+                initial_prompt=request.get("prompt") or None,
+                condition_on_previous_text=False,
+                fp16=model.device.type == "cuda",
+            )
+
+            for segment in result.get("segments", []):
+                segment.pop("tokens", None)
+
+            reply(out, {"id": request_id, "result": result})
+        except Exception as e:  # pylint: disable=broad-except
+            reply(out, {"id": request_id, "error": str(e)})
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,235 @@
+// Package action provides user-defined commands that run a program, declared in the config file.
+//
+// Only the declared programs can run. The arguments from the wire fill the argv templates of
+// the program, one argv element each, and never go through a shell. An argument starting with `-`
+// is rejected where the program would read it as an option, unless it follows `--`.
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// Action defaults.
+const (
+	// defaultTimeout kills the program when the action has no timeout.
+	defaultTimeout = 30 * time.Second
+	// maxOutput is the number of output bytes kept, the rest is dropped.
+	maxOutput = 4 * 1024
+	// waitDelay is how long the output is read after the program is killed, eg. from its children.
+	waitDelay = time.Second
+)
+
+// Config is the declaration of an action in the config file.
+type Config struct {
+	// Name is the name of the command, eg. `lock_screen`.
+	Name string `json:"name"`
+	// Description is a short description of the command.
+	Description string `json:"description"`
+	// Examples are example phrasings of the command.
+	Examples []string `json:"examples"`
+	// Params are the typed parameters of the command.
+	Params []command.Param `json:"params"`
+	// Command is the program and its arguments.
+	// The arguments are templates of the params, eg. `{{.message}}`.
+	Command []string `json:"command"`
+	// Dir is the working directory of the program, empty is the executor's.
+	// A leading `~` is the home directory.
+	Dir string `json:"dir"`
+	// Env are extra environment variables of the program.
+	Env map[string]string `json:"env"`
+	// Timeout kills the program after the duration, eg. `10s`.
+	Timeout string `json:"timeout"`
+}
+
+// Action is a command that runs a program.
+type Action struct {
+	debug bool
+
+	cmd     command.Command
+	program string
+	args    []*template.Template
+	// options are whether the arguments may start with `-`, ie. the template starts with it, or it follows `--`.
+	options []bool
+	dir     string
+	env     []string
+	timeout time.Duration
+}
+
+// New creates a new action.
+func New(cfg Config, debug bool) (*Action, error) {
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return nil, fmt.Errorf("action %q: command is required", cfg.Name)
+	}
+
+	// The program is fixed, only its arguments are templates.
+	if strings.Contains(cfg.Command[0], "{{") {
+		return nil, fmt.Errorf("action %q: program %q must not be a template", cfg.Name, cfg.Command[0])
+	}
+
+	timeout := defaultTimeout
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("action %q: invalid timeout %q", cfg.Name, cfg.Timeout)
+		}
+
+		timeout = d
+	}
+
+	dir := cfg.Dir
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("action %q: failed to find home directory: %w", cfg.Name, err)
+		}
+
+		dir = filepath.Join(home, dir[1:])
+	}
+
+	a := &Action{
+		debug:   debug,
+		program: cfg.Command[0],
+		dir:     dir,
+		timeout: timeout,
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(cfg.Env)) {
+		a.env = append(a.env, fmt.Sprintf("%s=%s", key, cfg.Env[key]))
+	}
+
+	// Check the templates only use the params, with every param set.
+	check := make(map[string]any, len(cfg.Params))
+	for _, p := range cfg.Params {
+		check[p.Name] = ""
+	}
+
+	endOfOptions := false
+	for i, arg := range cfg.Command[1:] {
+		tmpl, err := template.New(fmt.Sprintf("%s[%d]", cfg.Name, i+1)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("action %q: invalid argument template: %w", cfg.Name, err)
+		}
+
+		if err := tmpl.Execute(&bytes.Buffer{}, check); err != nil {
+			return nil, fmt.Errorf("action %q: invalid argument template: %w", cfg.Name, err)
+		}
+
+		a.args = append(a.args, tmpl)
+		a.options = append(a.options, endOfOptions || strings.HasPrefix(arg, "-"))
+
+		endOfOptions = endOfOptions || arg == "--"
+	}
+
+	a.cmd = command.Command{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Examples:    cfg.Examples,
+		Params:      cfg.Params,
+		Handler:     a.Run,
+	}
+
+	return a, nil
+}
+
+// Command returns the command of the action, with its handler.
+func (a *Action) Command() command.Command {
+	return a.cmd
+}
+
+// Run runs the program with the arguments, and returns its output.
+func (a *Action) Run(ctx context.Context, args command.Values) (string, error) {
+	// Missing optional params are empty.
+	data := make(map[string]any, len(a.cmd.Params))
+	for _, p := range a.cmd.Params {
+		data[p.Name] = ""
+	}
+	maps.Copy(data, args)
+
+	argv := make([]string, len(a.args))
+	for i, tmpl := range a.args {
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("failed to render argument: %w", err)
+		}
+
+		// A value must not turn into an option, eg. `--output=/etc/passwd`.
+		if !a.options[i] && strings.HasPrefix(b.String(), "-") {
+			return "", fmt.Errorf("%w: argument %q of action %q must not start with `-`", command.ErrInvalidArgs, b.String(), a.cmd.Name)
+		}
+
+		argv[i] = b.String()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	output := &limitedBuffer{limit: maxOutput}
+
+	cmd := exec.CommandContext(ctx, a.program, argv...)
+	cmd.Dir = a.dir
+	cmd.Env = append(os.Environ(), a.env...)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.WaitDelay = waitDelay
+
+	if a.debug {
+		log.Println(fmt.Sprintf("running action %q: %s %q", a.cmd.Name, a.program, argv))
+	}
+
+	err := cmd.Run()
+	result := strings.TrimSpace(output.String())
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("action %q timed out after %s", a.cmd.Name, a.timeout)
+	} else if err != nil {
+		err = fmt.Errorf("action %q failed: %w", a.cmd.Name, err)
+	}
+
+	// Keep the output of the failure, eg. the usage of the program.
+	if err != nil && result != "" {
+		return "", fmt.Errorf("%w: %s", err, result)
+	}
+
+	return result, err
+}
+
+// limitedBuffer keeps the first bytes written to it, and drops the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write writes the bytes that fit, and reports all of them as written so the program is not interrupted.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room < len(p) {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
+
+// String returns the kept bytes, marking the truncation.
+func (b *limitedBuffer) String() string {
+	if b.truncated {
+		return b.Buffer.String() + "…"
+	}
+
+	return b.Buffer.String()
+}
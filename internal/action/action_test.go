@@ -0,0 +1,46 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+func TestRunOptionInjection(t *testing.T) {
+	params := []command.Param{{Name: "message", Type: command.TypeText, Required: true}}
+
+	tests := []struct {
+		name    string
+		command []string
+		message string
+		want    string
+		wantErr error
+	}{
+		{name: "plain value", command: []string{"echo", "{{.message}}"}, message: "hello", want: "hello"},
+		{name: "value as an option", command: []string{"echo", "{{.message}}"}, message: "-e hello", wantErr: command.ErrInvalidArgs},
+		{name: "value as a long option", command: []string{"echo", "say {{.message}}", "{{.message}}"}, message: "--help", wantErr: command.ErrInvalidArgs},
+		{name: "value inside an option", command: []string{"echo", "--message={{.message}}"}, message: "-e", want: "--message=-e"},
+		{name: "value after the end of options", command: []string{"echo", "--", "{{.message}}"}, message: "-e hello", want: "-- -e hello"},
+		{name: "dash inside the value", command: []string{"echo", "{{.message}}"}, message: "well-known", want: "well-known"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := New(Config{Name: "say", Params: params, Command: tt.command}, false)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+
+			got, err := a.Run(context.Background(), command.Values{"message": tt.message})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run(%q) error = %v, want %v", tt.message, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Run(%q) = %q, want %q", tt.message, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// Package actuator provides keyboard and mouse inputs.
+package actuator
+
+import "fmt"
+
+// Backends.
+const (
+	// BackendRobotgo sends the inputs to the OS using robotgo.
+	BackendRobotgo = "robotgo"
+	// BackendRecorder records the inputs in memory, eg. for headless runs.
+	BackendRecorder = "recorder"
+)
+
+// Actuator sends keyboard and mouse inputs.
+type Actuator interface {
+	// KeyTap taps the key while holding the modifiers, eg. `KeyTap("f", "shift")`.
+	KeyTap(key string, modifiers ...string) error
+	// Type types the text.
+	Type(text string) error
+	// Move moves the mouse to the screen position.
+	Move(x, y int) error
+	// Click clicks the mouse button, eg. `left`, `right`, twice if double.
+	Click(button string, double bool) error
+	// Scroll scrolls the mouse wheel, positive y scrolls up and positive x scrolls right.
+	Scroll(x, y int) error
+	// FocusedWindow returns the window that receives the inputs.
+	FocusedWindow() (Window, error)
+}
+
+// Window is a window on the screen.
+type Window struct {
+	// Title is the title of the window, eg. `Video - YouTube - Mozilla Firefox`.
+	Title string
+	// Process is the name of the process that owns the window, eg. `firefox`.
+	Process string
+}
+
+// Config is the configuration for the actuator.
+type Config struct {
+	// Backend is the backend of the actuator, eg. `robotgo` or `recorder`.
+	Backend string
+	// Debug enables logging the inputs.
+	Debug bool
+}
+
+// New creates the actuator for the backend.
+func New(cfg Config) (Actuator, error) {
+	switch cfg.Backend {
+	case BackendRobotgo:
+		robotgo, err := NewRobotgo(cfg.Debug)
+		if err != nil {
+			return nil, err
+		}
+
+		return robotgo, nil
+
+	case BackendRecorder:
+		return NewRecorder(cfg.Debug), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported actuator backend: %q", cfg.Backend)
+	}
+}
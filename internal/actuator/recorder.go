@@ -0,0 +1,139 @@
+package actuator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// InputKind is the kind of a recorded input.
+type InputKind string
+
+// Input kinds.
+const (
+	InputKeyTap InputKind = "key_tap"
+	InputType   InputKind = "type"
+	InputMove   InputKind = "move"
+	InputClick  InputKind = "click"
+	InputScroll InputKind = "scroll"
+)
+
+// Input is a recorded input.
+type Input struct {
+	Kind      InputKind
+	Key       string
+	Modifiers []string
+	Text      string
+	X         int
+	Y         int
+	Button    string
+	Double    bool
+}
+
+// String formats the input, eg. `key_tap shift+f`.
+func (i Input) String() string {
+	switch i.Kind {
+	case InputKeyTap:
+		return fmt.Sprintf("%s %s", i.Kind, strings.Join(append(append([]string{}, i.Modifiers...), i.Key), "+"))
+	case InputType:
+		return fmt.Sprintf("%s %q", i.Kind, i.Text)
+	case InputClick:
+		if i.Double {
+			return fmt.Sprintf("%s %s double", i.Kind, i.Button)
+		}
+		return fmt.Sprintf("%s %s", i.Kind, i.Button)
+	default:
+		return fmt.Sprintf("%s %d,%d", i.Kind, i.X, i.Y)
+	}
+}
+
+// Recorder records the inputs in memory instead of sending them to the OS.
+type Recorder struct {
+	debug bool
+
+	mu     sync.Mutex
+	inputs []Input
+	window Window
+}
+
+// NewRecorder creates a new recording actuator.
+func NewRecorder(debug bool) *Recorder {
+	return &Recorder{
+		debug: debug,
+	}
+}
+
+// KeyTap records a key tap.
+func (r *Recorder) KeyTap(key string, modifiers ...string) error {
+	return r.record(Input{Kind: InputKeyTap, Key: key, Modifiers: modifiers})
+}
+
+// Type records typed text.
+func (r *Recorder) Type(text string) error {
+	return r.record(Input{Kind: InputType, Text: text})
+}
+
+// Move records a mouse move.
+func (r *Recorder) Move(x, y int) error {
+	return r.record(Input{Kind: InputMove, X: x, Y: y})
+}
+
+// Click records a mouse click.
+func (r *Recorder) Click(button string, double bool) error {
+	return r.record(Input{Kind: InputClick, Button: button, Double: double})
+}
+
+// Scroll records a mouse scroll.
+func (r *Recorder) Scroll(x, y int) error {
+	return r.record(Input{Kind: InputScroll, X: x, Y: y})
+}
+
+// FocusedWindow returns the window set with SetWindow, it is not recorded.
+func (r *Recorder) FocusedWindow() (Window, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.window, nil
+}
+
+// SetWindow sets the focused window, eg. to select a keymap profile.
+func (r *Recorder) SetWindow(window Window) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.window = window
+}
+
+// Inputs returns the recorded inputs, in order.
+func (r *Recorder) Inputs() []Input {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inputs := make([]Input, len(r.inputs))
+	copy(inputs, r.inputs)
+
+	return inputs
+}
+
+// Reset clears the recorded inputs.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inputs = nil
+}
+
+// record appends the input.
+func (r *Recorder) record(input Input) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.inputs = append(r.inputs, input)
+
+	if r.debug {
+		log.Println(fmt.Sprintf("recorded input: %s", input))
+	}
+
+	return nil
+}
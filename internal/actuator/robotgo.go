@@ -0,0 +1,98 @@
+//go:build !norobotgo
+
+package actuator
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/go-vgo/robotgo"
+)
+
+// Robotgo sends the inputs to the OS using robotgo.
+type Robotgo struct {
+	debug bool
+}
+
+// NewRobotgo creates a new robotgo actuator.
+func NewRobotgo(debug bool) (*Robotgo, error) {
+	return &Robotgo{
+		debug: debug,
+	}, nil
+}
+
+// KeyTap taps the key while holding the modifiers.
+func (r *Robotgo) KeyTap(key string, modifiers ...string) error {
+	args := make([]any, len(modifiers))
+	for i, m := range modifiers {
+		args[i] = m
+	}
+
+	if err := robotgo.KeyTap(key, args...); err != nil {
+		return fmt.Errorf("failed to tap key %q: %w", strings.Join(append(modifiers, key), "+"), err)
+	}
+
+	if r.debug {
+		log.Println(fmt.Sprintf("key tap: %s", strings.Join(append(modifiers, key), "+")))
+	}
+
+	return nil
+}
+
+// Type types the text.
+func (r *Robotgo) Type(text string) error {
+	robotgo.TypeStr(text)
+
+	if r.debug {
+		log.Println(fmt.Sprintf("type: %q", text))
+	}
+
+	return nil
+}
+
+// Move moves the mouse to the screen position.
+func (r *Robotgo) Move(x, y int) error {
+	robotgo.Move(x, y)
+
+	if r.debug {
+		log.Println(fmt.Sprintf("move: %d,%d", x, y))
+	}
+
+	return nil
+}
+
+// Click clicks the mouse button.
+func (r *Robotgo) Click(button string, double bool) error {
+	robotgo.Click(button, double)
+
+	if r.debug {
+		log.Println(fmt.Sprintf("click: %s (double: %t)", button, double))
+	}
+
+	return nil
+}
+
+// Scroll scrolls the mouse wheel.
+func (r *Robotgo) Scroll(x, y int) error {
+	robotgo.Scroll(x, y)
+
+	if r.debug {
+		log.Println(fmt.Sprintf("scroll: %d,%d", x, y))
+	}
+
+	return nil
+}
+
+// FocusedWindow returns the focused window.
+func (r *Robotgo) FocusedWindow() (Window, error) {
+	process, err := robotgo.FindName(robotgo.GetPid())
+	if err != nil {
+		return Window{}, fmt.Errorf("failed to find focused process: %w", err)
+	}
+
+	return Window{
+		Title:   robotgo.GetTitle(),
+		Process: process,
+	}, nil
+}
@@ -0,0 +1,15 @@
+//go:build norobotgo
+
+package actuator
+
+import "fmt"
+
+// Robotgo is unavailable when built with the `norobotgo` tag.
+type Robotgo struct {
+	Actuator
+}
+
+// NewRobotgo fails because the binary was built without robotgo, eg. for headless CI.
+func NewRobotgo(_ bool) (*Robotgo, error) {
+	return nil, fmt.Errorf("robotgo actuator unavailable: built with the %q tag", "norobotgo")
+}
@@ -0,0 +1,157 @@
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Args are the raw arguments of a command, keyed by parameter name.
+type Args map[string]string
+
+// Values are the parsed arguments of a command, keyed by parameter name.
+type Values map[string]any
+
+// Invocation is a request to execute a command with arguments.
+type Invocation struct {
+	// Name is the name of the command.
+	Name string
+	// Args are the raw arguments of the command.
+	Args Args
+}
+
+// String formats the invocation as `name key=value key="quoted value"`.
+func (i Invocation) String() string {
+	keys := make([]string, 0, len(i.Args))
+	for key := range i.Args {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	parts := []string{i.Name}
+	for _, key := range keys {
+		value := i.Args[key]
+		if value == "" || strings.ContainsFunc(value, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '"' || r == '='
+		}) {
+			value = strconv.Quote(value)
+		}
+
+		parts = append(parts, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ParseInvocation parses an invocation formatted by Invocation.String.
+func ParseInvocation(s string) (Invocation, error) {
+	tokens, err := tokenize(strings.TrimSpace(s))
+	if err != nil {
+		return Invocation{}, err
+	}
+
+	if len(tokens) == 0 {
+		return Invocation{}, fmt.Errorf("empty invocation")
+	}
+
+	inv := Invocation{
+		Name: tokens[0],
+		Args: Args{},
+	}
+
+	for _, token := range tokens[1:] {
+		key, value, ok := strings.Cut(token, "=")
+		if !ok || key == "" {
+			return Invocation{}, fmt.Errorf("%w: expected key=value, got %q", ErrInvalidArgs, token)
+		}
+
+		inv.Args[key] = value
+	}
+
+	return inv, nil
+}
+
+// tokenize splits the string on spaces, keeping quoted values together.
+func tokenize(s string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			// Find the closing quote, skipping escaped characters, eg. `\"` but not the quote after `\\`.
+			end := i + 1
+			for escaped := false; end < len(s) && (escaped || s[end] != '"'); end++ {
+				escaped = !escaped && s[end] == '\\'
+			}
+
+			if end >= len(s) {
+				return nil, fmt.Errorf("%w: unterminated quote in %q", ErrInvalidArgs, s)
+			}
+
+			unquoted, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: malformed quote in %q", ErrInvalidArgs, s)
+			}
+
+			current.WriteString(unquoted)
+			i = end
+
+		case c == ' ' || c == '\t':
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+
+	return tokens, nil
+}
+
+// Bool returns the switch value of the parameter.
+func (v Values) Bool(name string) bool {
+	b, _ := v[name].(bool)
+	return b
+}
+
+// Duration returns the duration value of the parameter, or the time of day as the duration since midnight.
+func (v Values) Duration(name string) time.Duration {
+	d, _ := v[name].(time.Duration)
+	return d
+}
+
+// Int returns the integer or percentage value of the parameter.
+func (v Values) Int(name string) int {
+	n, _ := v[name].(int)
+	return n
+}
+
+// Number returns the decimal value of the parameter.
+func (v Values) Number(name string) float64 {
+	n, _ := v[name].(float64)
+	return n
+}
+
+// Text returns the text value of the parameter.
+func (v Values) Text(name string) string {
+	s, _ := v[name].(string)
+	return s
+}
+
+// Has checks if the parameter has a value.
+func (v Values) Has(name string) bool {
+	_, ok := v[name]
+	return ok
+}
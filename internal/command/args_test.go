@@ -0,0 +1,130 @@
+package command
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestParseInvocation(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Invocation
+	}{
+		{
+			name:  "no arguments",
+			input: "pause_video",
+			want:  Invocation{Name: "pause_video", Args: Args{}},
+		},
+		{
+			name:  "plain arguments",
+			input: "seek_forward seconds=30s",
+			want:  Invocation{Name: "seek_forward", Args: Args{"seconds": "30s"}},
+		},
+		{
+			name:  "extra spaces",
+			input: "  seek_forward \t seconds=30s  ",
+			want:  Invocation{Name: "seek_forward", Args: Args{"seconds": "30s"}},
+		},
+		{
+			name:  "quoted value",
+			input: `type_text text="hello world"`,
+			want:  Invocation{Name: "type_text", Args: Args{"text": "hello world"}},
+		},
+		{
+			name:  "empty quoted value",
+			input: `type_text text=""`,
+			want:  Invocation{Name: "type_text", Args: Args{"text": ""}},
+		},
+		{
+			name:  "escaped quote",
+			input: `type_text text="say \"hi\""`,
+			want:  Invocation{Name: "type_text", Args: Args{"text": `say "hi"`}},
+		},
+		{
+			name:  "escaped backslash before the closing quote",
+			input: `type_text text="a\\" player=vlc`,
+			want:  Invocation{Name: "type_text", Args: Args{"text": `a\`, "player": "vlc"}},
+		},
+		{
+			name:  "escaped backslash then escaped quote",
+			input: `type_text text="a\\\"b"`,
+			want:  Invocation{Name: "type_text", Args: Args{"text": `a\"b`}},
+		},
+		{
+			name:  "equals in quoted value",
+			input: `type_text text="a=b"`,
+			want:  Invocation{Name: "type_text", Args: Args{"text": "a=b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseInvocation(tt.input)
+			if err != nil {
+				t.Fatalf("ParseInvocation(%q) failed: %v", tt.input, err)
+			}
+
+			if got.Name != tt.want.Name || !maps.Equal(got.Args, tt.want.Args) {
+				t.Errorf("ParseInvocation(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvocationErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: "   "},
+		{name: "unterminated quote", input: `type_text text="hello`},
+		{name: "quote ending with an escaped quote", input: `type_text text="hello\"`},
+		{name: "malformed escape", input: `type_text text="\q"`},
+		{name: "missing value", input: "seek_forward seconds"},
+		{name: "missing key", input: "seek_forward =30s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseInvocation(tt.input); err == nil {
+				t.Errorf("ParseInvocation(%q) succeeded, want an error", tt.input)
+			}
+		})
+	}
+}
+
+func TestParseInvocationInvalidArgs(t *testing.T) {
+	_, err := ParseInvocation(`type_text text="hello`)
+	if !errors.Is(err, ErrInvalidArgs) {
+		t.Errorf("ParseInvocation() error = %v, want %v", err, ErrInvalidArgs)
+	}
+}
+
+func TestInvocationStringRoundTrip(t *testing.T) {
+	tests := []Invocation{
+		{Name: "pause_video", Args: Args{}},
+		{Name: "seek_forward", Args: Args{"seconds": "30s"}},
+		{Name: "type_text", Args: Args{"text": "hello world"}},
+		{Name: "type_text", Args: Args{"text": ""}},
+		{Name: "type_text", Args: Args{"text": `say "hi"`}},
+		{Name: "type_text", Args: Args{"text": `a\`}},
+		{Name: "type_text", Args: Args{"text": `C:\path\"quoted"\`}},
+		{Name: "type_text", Args: Args{"text": "a=b"}},
+		{Name: "schedule_command", Args: Args{"command": `type_text text="x"`, "delay": "10m"}},
+	}
+
+	for _, inv := range tests {
+		t.Run(inv.String(), func(t *testing.T) {
+			got, err := ParseInvocation(inv.String())
+			if err != nil {
+				t.Fatalf("ParseInvocation(%q) failed: %v", inv.String(), err)
+			}
+
+			if got.Name != inv.Name || !maps.Equal(got.Args, inv.Args) {
+				t.Errorf("ParseInvocation(%q) = %+v, want %+v", inv.String(), got, inv)
+			}
+		})
+	}
+}
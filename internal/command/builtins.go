@@ -0,0 +1,380 @@
+package command
+
+// Builtins is the list of commands that the executor can execute.
+var Builtins = []Command{
+	{
+		Name:        "pause_video",
+		Description: "pause the video",
+		Examples: []string{
+			"pause the video",
+			"stop the video",
+		},
+	},
+	{
+		Name:        "play_video",
+		Description: "play the video",
+		Examples: []string{
+			"play the video",
+			"resume the video",
+		},
+	},
+	{
+		Name:        "seek_forward",
+		Description: "skip the video forward",
+		Examples: []string{
+			"skip forward 30 seconds",
+			"fast forward a minute",
+		},
+		Params: []Param{
+			{Name: "seconds", Type: TypeDuration, Description: "how far to skip", Default: "10s", Min: 1, Max: 3600},
+		},
+	},
+	{
+		Name:        "seek_backward",
+		Description: "rewind the video",
+		Examples: []string{
+			"go back 10 seconds",
+			"rewind 2 minutes",
+		},
+		Params: []Param{
+			{Name: "seconds", Type: TypeDuration, Description: "how far to rewind", Default: "10s", Min: 1, Max: 3600},
+		},
+	},
+	{
+		Name:        "set_volume",
+		Description: "set the video volume",
+		Examples: []string{
+			"set volume to 40",
+			"volume 80 percent",
+		},
+		Params: []Param{
+			{Name: "level", Type: TypePercent, Description: "the volume level", Required: true},
+		},
+	},
+	{
+		Name:        "set_speed",
+		Description: "set the video playback speed",
+		Examples: []string{
+			"set speed to 1.5",
+			"play at double speed",
+		},
+		Params: []Param{
+			{Name: "speed", Type: TypeNumber, Description: "the playback rate", Required: true, Min: 0.25, Max: 2},
+		},
+	},
+	{
+		Name:        "jump_to_percent",
+		Description: "jump to a position of the video, rounded to 10%",
+		Examples: []string{
+			"jump to the middle of the video",
+			"go to 70 percent",
+		},
+		Params: []Param{
+			{Name: "percent", Type: TypePercent, Description: "the position in the video", Required: true},
+		},
+	},
+	{
+		Name:        "next_video",
+		Description: "play the next video",
+		Examples: []string{
+			"next video",
+			"skip this video",
+		},
+	},
+	{
+		Name:        "previous_video",
+		Description: "play the previous video",
+		Examples: []string{
+			"previous video",
+			"go back to the last video",
+		},
+	},
+	{
+		Name:        "mute_video",
+		Description: "mute the video",
+		Examples: []string{
+			"mute",
+			"mute the video",
+		},
+	},
+	{
+		Name:        "unmute_video",
+		Description: "unmute the video",
+		Examples: []string{
+			"unmute",
+			"turn the sound back on",
+		},
+	},
+	{
+		Name:        "volume_up",
+		Description: "turn the video volume up",
+		Examples: []string{
+			"louder",
+			"turn it up by 20",
+		},
+		Params: []Param{
+			{Name: "amount", Type: TypePercent, Description: "how much louder", Default: "10", Min: 1, Max: 100},
+		},
+	},
+	{
+		Name:        "volume_down",
+		Description: "turn the video volume down",
+		Examples: []string{
+			"quieter",
+			"turn it down a bit",
+		},
+		Params: []Param{
+			{Name: "amount", Type: TypePercent, Description: "how much quieter", Default: "10", Min: 1, Max: 100},
+		},
+	},
+	{
+		Name:        "toggle_fullscreen",
+		Description: "enter or exit fullscreen",
+		Examples: []string{
+			"fullscreen",
+			"exit fullscreen",
+		},
+	},
+	{
+		Name:        "toggle_theater_mode",
+		Description: "enter or exit theater mode",
+		Examples: []string{
+			"theater mode",
+			"make the player wider",
+		},
+	},
+	{
+		Name:        "toggle_captions",
+		Description: "turn the captions on or off",
+		Examples: []string{
+			"turn on subtitles",
+			"hide the captions",
+		},
+	},
+	{
+		Name:        "speed_up",
+		Description: "play the video faster",
+		Examples: []string{
+			"faster",
+			"speed it up",
+		},
+		Params: []Param{
+			{Name: "amount", Type: TypeNumber, Description: "how much faster", Default: "0.25", Min: 0.25, Max: 1.75},
+		},
+	},
+	{
+		Name:        "speed_down",
+		Description: "play the video slower",
+		Examples: []string{
+			"slower",
+			"slow it down",
+		},
+		Params: []Param{
+			{Name: "amount", Type: TypeNumber, Description: "how much slower", Default: "0.25", Min: 0.25, Max: 1.75},
+		},
+	},
+	{
+		Name:        "skip_ad",
+		Description: "skip the ad",
+		Examples: []string{
+			"skip ad",
+			"skip this ad",
+		},
+	},
+}
+
+// playerParam is the optional media player of the media commands, the active one when missing.
+var playerParam = Param{Name: "player", Type: TypeText, Description: "the media player, eg. spotify, vlc, mpv"}
+
+// MediaBuiltins is the list of commands that control media players, eg. Spotify, VLC, and mpv.
+var MediaBuiltins = []Command{
+	{
+		Name:        "list_players",
+		Description: "list the open media players",
+		Examples: []string{
+			"which players are open",
+			"list the media players",
+		},
+		ReadOnly: true,
+	},
+	{
+		Name:        "play_media",
+		Description: "play the music or media player",
+		Examples: []string{
+			"play the music",
+			"resume spotify",
+		},
+		Params: []Param{playerParam},
+	},
+	{
+		Name:        "pause_media",
+		Description: "pause the music or media player",
+		Examples: []string{
+			"pause the music",
+			"pause spotify",
+		},
+		Params: []Param{playerParam},
+	},
+	{
+		Name:        "next_track",
+		Description: "skip to the next track of the media player",
+		Examples: []string{
+			"next song",
+			"skip this track",
+		},
+		Params: []Param{playerParam},
+	},
+	{
+		Name:        "previous_track",
+		Description: "go back to the previous track of the media player",
+		Examples: []string{
+			"previous song",
+			"play the last track again",
+		},
+		Params: []Param{playerParam},
+	},
+	{
+		Name:        "seek_media",
+		Description: "skip the media player forward or backward",
+		Examples: []string{
+			"skip the song forward 30 seconds",
+			"rewind vlc 1 minute",
+		},
+		Params: []Param{
+			{Name: "seconds", Type: TypeDuration, Description: "how far to skip", Default: "10s", Min: 1, Max: 3600},
+			{Name: "backward", Type: TypeBool, Description: "whether to rewind", Default: "off"},
+			playerParam,
+		},
+	},
+	{
+		Name:        "set_media_volume",
+		Description: "set the volume of the media player",
+		Examples: []string{
+			"set the music volume to 30",
+			"spotify volume 50 percent",
+		},
+		Params: []Param{
+			{Name: "level", Type: TypePercent, Description: "the volume level", Required: true},
+			playerParam,
+		},
+	},
+	{
+		Name:        "set_shuffle",
+		Description: "turn the shuffle of the media player on or off",
+		Examples: []string{
+			"shuffle the playlist",
+			"turn off shuffle",
+		},
+		Params: []Param{
+			{Name: "shuffle", Type: TypeBool, Description: "on or off", Required: true},
+			playerParam,
+		},
+	},
+}
+
+// DictationBuiltins is the list of commands that type dictated text into the focused window.
+var DictationBuiltins = []Command{
+	{
+		Name:        "type_text",
+		Description: "type the text into the focused window, with spoken punctuation",
+		Examples: []string{
+			"type on my way comma see you soon",
+			"type hello new line",
+		},
+		Params: []Param{
+			{Name: "text", Type: TypeText, Description: "the text to type", Required: true},
+		},
+	},
+	{
+		Name:        "undo_dictation",
+		Description: "delete the last typed text",
+		Examples: []string{
+			"scratch that",
+			"undo that",
+		},
+	},
+}
+
+// SchedulerBuiltins is the list of commands that run other commands later.
+var SchedulerBuiltins = []Command{
+	{
+		Name:        "schedule_command",
+		Description: "run a command after a delay or at a time of day",
+		Examples: []string{
+			"pause the video in 10 minutes",
+			"play the music again at 8pm",
+		},
+		Params: []Param{
+			{Name: "command", Type: TypeText, Description: "the command to run, eg. `seek_forward seconds=30`", Required: true},
+			{Name: "delay", Type: TypeDuration, Description: "how long to wait", Min: 1, Max: 7 * 24 * 3600},
+			{Name: "at", Type: TypeTime, Description: "the time of day to run at"},
+		},
+	},
+	{
+		Name:        "list_scheduled_commands",
+		Description: "list the scheduled commands",
+		Examples: []string{
+			"what is scheduled",
+			"list the scheduled commands",
+		},
+		ReadOnly: true,
+	},
+	{
+		Name:        "cancel_scheduled_commands",
+		Description: "cancel the scheduled commands, or only those of one command",
+		Examples: []string{
+			"cancel the scheduled commands",
+			"don't pause the video later",
+		},
+		Params: []Param{
+			{Name: "command", Type: TypeText, Description: "the name of the command to cancel, eg. `pause_video`"},
+		},
+	},
+}
+
+// TimerBuiltins is the list of commands that run kitchen timers, handled by the listener.
+var TimerBuiltins = []Command{
+	{
+		Name:        "set_timer",
+		Description: "start a kitchen timer that rings an alarm when done",
+		Examples: []string{
+			"set a timer for 8 minutes",
+			"set a pasta timer for 10 minutes",
+		},
+		Params: []Param{
+			{Name: "duration", Type: TypeDuration, Description: "how long the timer runs", Required: true, Min: 1, Max: 24 * 3600},
+			{Name: "name", Type: TypeText, Description: "the name of the timer, eg. pasta"},
+		},
+	},
+	{
+		Name:        "timer_status",
+		Description: "tell how long is left on the timers",
+		Examples: []string{
+			"how long left",
+			"how much time is left on the pasta timer",
+		},
+		Params: []Param{
+			{Name: "name", Type: TypeText, Description: "the name of the timer, all of them when missing"},
+		},
+		ReadOnly: true,
+	},
+	{
+		Name:        "cancel_timer",
+		Description: "cancel the kitchen timers",
+		Examples: []string{
+			"cancel the timer",
+			"cancel the pasta timer",
+		},
+		Params: []Param{
+			{Name: "name", Type: TypeText, Description: "the name of the timer, all of them when missing"},
+		},
+	},
+	{
+		Name:        "stop_alarm",
+		Description: "stop the ringing timer alarm",
+		Examples: []string{
+			"stop the alarm",
+			"turn off the alarm",
+		},
+	},
+}
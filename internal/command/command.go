@@ -0,0 +1,78 @@
+// Package command provides a registry of the commands understood by Jarvis.
+package command
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// HandlerFunc is the callback for executing a command.
+// The result is an optional output of the command, returned to the sender.
+type HandlerFunc func(ctx context.Context, args Values) (result string, err error)
+
+// Command is a command that the executor can execute.
+type Command struct {
+	// Name is the unique name of the command, eg. `pause_video`.
+	Name string
+	// Description is a short description of what the command does.
+	Description string
+	// Examples are phrasings that should be interpreted as the command.
+	Examples []string
+	// Params are the typed parameters of the command.
+	Params []Param
+	// ReadOnly commands only report the state, eg. `list_players`, so they don't interrupt a running macro.
+	ReadOnly bool
+	// Handler is the callback for executing the command.
+	// It is only set on the executor, the listener only needs the description.
+	Handler HandlerFunc
+}
+
+// Usage returns the command as `name key=<type>`.
+func (c Command) Usage() string {
+	parts := []string{c.Name}
+	for _, p := range c.Params {
+		parts = append(parts, p.Usage())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Parse validates the raw arguments and parses them into values.
+func (c Command) Parse(args Args) (Values, error) {
+	for name := range args {
+		if !slices.ContainsFunc(c.Params, func(p Param) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("%s: %w: unknown parameter %q", c.Name, ErrInvalidArgs, name)
+		}
+	}
+
+	values := make(Values, len(c.Params))
+	for _, p := range c.Params {
+		raw, ok := args[p.Name]
+		if !ok {
+			raw = p.Default
+		}
+
+		if raw == "" {
+			if p.Required {
+				return nil, fmt.Errorf("%s: %w: missing parameter %q", c.Name, ErrInvalidArgs, p.Name)
+			}
+
+			continue
+		}
+
+		value, err := p.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Name, err)
+		}
+
+		values[p.Name] = value
+	}
+
+	return values, nil
+}
+
+// nameRegex is the regex for valid command names.
+var nameRegex = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
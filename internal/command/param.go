@@ -0,0 +1,236 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidArgs is returned when the arguments of a command are malformed.
+var ErrInvalidArgs = errors.New("invalid arguments")
+
+// ParamType is the type of a command parameter.
+type ParamType string
+
+// Param types.
+const (
+	// TypeBool is a switch, eg. `on`, `off`, `true`, `false`.
+	TypeBool ParamType = "bool"
+	// TypeDuration is a duration, eg. `30`, `30s`, `1m30s`, `2 minutes`.
+	TypeDuration ParamType = "duration"
+	// TypeInt is an integer, eg. `3`.
+	TypeInt ParamType = "int"
+	// TypeNumber is a decimal number, eg. `1.5`.
+	TypeNumber ParamType = "number"
+	// TypePercent is a percentage, eg. `40`, `40%`.
+	TypePercent ParamType = "percent"
+	// TypeText is free text, eg. `hello world`.
+	TypeText ParamType = "text"
+	// TypeTime is a time of day, eg. `8pm`, `8:30 am`, `20:30`, parsed as the duration since midnight.
+	TypeTime ParamType = "time"
+)
+
+// valid checks the type is supported.
+func (t ParamType) valid() bool {
+	switch t {
+	case TypeBool, TypeDuration, TypeInt, TypeNumber, TypePercent, TypeText, TypeTime:
+		return true
+	default:
+		return false
+	}
+}
+
+// Param is a typed parameter of a command.
+type Param struct {
+	// Name is the name of the parameter, eg. `seconds`.
+	Name string
+	// Type is the type of the parameter.
+	Type ParamType
+	// Description is a short description of the parameter.
+	Description string
+	// Required fails the command if the parameter is missing.
+	Required bool
+	// Default is the raw value used when the parameter is missing.
+	Default string
+	// Min is the minimum value, in seconds for durations.
+	Min float64
+	// Max is the maximum value, in seconds for durations.
+	// When both Min and Max are zero, the range is not checked.
+	Max float64
+}
+
+// durationRegex is the regex for spoken durations, eg. `30 seconds`.
+var durationRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(s|secs?|seconds?|m|mins?|minutes?|h|hrs?|hours?)$`)
+
+// timeRegex is the regex for times of day, eg. `8pm`, `8:30 a.m.`, `20:30`.
+var timeRegex = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?\s*(am|pm)?$`)
+
+// Usage returns the parameter as `name=<type>`.
+func (p Param) Usage() string {
+	return fmt.Sprintf("%s=<%s>", p.Name, p.Type)
+}
+
+// Parse parses and range checks the raw value of the parameter.
+func (p Param) Parse(raw string) (any, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("%w: %s is empty", ErrInvalidArgs, p.Name)
+	}
+
+	switch p.Type {
+	case TypeBool:
+		switch strings.ToLower(raw) {
+		case "on", "true", "yes", "enable", "enabled":
+			return true, nil
+		case "off", "false", "no", "disable", "disabled":
+			return false, nil
+		default:
+			return nil, fmt.Errorf("%w: %s: %q is not on or off", ErrInvalidArgs, p.Name, raw)
+		}
+
+	case TypeDuration:
+		d, err := parseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidArgs, p.Name, err)
+		}
+
+		return d, p.checkRange(d.Seconds())
+
+	case TypeInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %q is not an integer", ErrInvalidArgs, p.Name, raw)
+		}
+
+		return n, p.checkRange(float64(n))
+
+	case TypeNumber:
+		n, err := strconv.ParseFloat(strings.TrimSuffix(raw, "x"), 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return nil, fmt.Errorf("%w: %s: %q is not a number", ErrInvalidArgs, p.Name, raw)
+		}
+
+		return n, p.checkRange(n)
+
+	case TypePercent:
+		trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(raw, "percent"), "%"))
+		n, err := strconv.Atoi(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %q is not a percentage", ErrInvalidArgs, p.Name, raw)
+		}
+
+		if n < 0 || n > 100 {
+			return nil, fmt.Errorf("%w: %s: %d%% is out of range [0%%, 100%%]", ErrInvalidArgs, p.Name, n)
+		}
+
+		return n, p.checkRange(float64(n))
+
+	case TypeText:
+		return raw, nil
+
+	case TypeTime:
+		t, err := parseTime(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrInvalidArgs, p.Name, err)
+		}
+
+		return t, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s: unsupported type %q", ErrInvalidArgs, p.Name, p.Type)
+	}
+}
+
+// checkRange checks the value is within the parameter range.
+func (p Param) checkRange(v float64) error {
+	if p.Min == 0 && p.Max == 0 {
+		return nil
+	}
+
+	if v < p.Min || v > p.Max {
+		return fmt.Errorf("%w: %s: %v is out of range [%v, %v]", ErrInvalidArgs, p.Name, v, p.Min, p.Max)
+	}
+
+	return nil
+}
+
+// parseDuration parses a duration, plain numbers are seconds.
+func parseDuration(raw string) (time.Duration, error) {
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return time.Duration(n * float64(time.Second)), nil
+	}
+
+	if d, err := time.ParseDuration(raw); err == nil {
+		return d, nil
+	}
+
+	parts := durationRegex.FindStringSubmatch(strings.ToLower(raw))
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("%q is not a duration", raw)
+	}
+
+	n, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration", raw)
+	}
+
+	unit := time.Second
+	switch parts[2][0] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	}
+
+	return time.Duration(n * float64(unit)), nil
+}
+
+// parseTime parses a time of day as the duration since midnight, plain hours are on a 24-hour clock.
+func parseTime(raw string) (time.Duration, error) {
+	normalized := strings.ReplaceAll(strings.ToLower(raw), ".m.", "m")
+	switch normalized {
+	case "midnight":
+		return 0, nil
+	case "noon":
+		return 12 * time.Hour, nil
+	}
+
+	parts := timeRegex.FindStringSubmatch(normalized)
+	if len(parts) != 4 {
+		return 0, fmt.Errorf("%q is not a time of day", raw)
+	}
+
+	hour, _ := strconv.Atoi(parts[1])
+	minute := 0
+	if parts[2] != "" {
+		minute, _ = strconv.Atoi(parts[2])
+	}
+
+	if minute > 59 {
+		return 0, fmt.Errorf("%q is not a time of day", raw)
+	}
+
+	switch parts[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, fmt.Errorf("%q is not a time of day", raw)
+		}
+
+		// 12am is midnight, and 12pm is noon.
+		hour %= 12
+		if parts[3] == "pm" {
+			hour += 12
+		}
+
+	default:
+		if hour > 23 {
+			return 0, fmt.Errorf("%q is not a time of day", raw)
+		}
+	}
+
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, nil
+}
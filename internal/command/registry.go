@@ -0,0 +1,186 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrUnknownCommand is returned when a command is not registered.
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrNoHandler is returned when a command has no handler.
+	ErrNoHandler = errors.New("command has no handler")
+)
+
+// Registry holds the commands in the order they were registered.
+type Registry struct {
+	commands []Command
+	index    map[string]int
+}
+
+// NewRegistry creates a new registry from the commands.
+func NewRegistry(commands ...Command) (*Registry, error) {
+	r := &Registry{
+		index: make(map[string]int, len(commands)),
+	}
+
+	for _, cmd := range commands {
+		if err := r.Register(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// NewDefaultRegistry creates a new registry from the builtin commands.
+func NewDefaultRegistry() (*Registry, error) {
+	return NewRegistry(slices.Concat(Builtins, MediaBuiltins, DictationBuiltins, SchedulerBuiltins)...)
+}
+
+// Register adds a command to the registry.
+func (r *Registry) Register(cmd Command) error {
+	if !nameRegex.MatchString(cmd.Name) {
+		return fmt.Errorf("invalid command name %q: must be snake_case", cmd.Name)
+	}
+
+	if cmd.Description == "" {
+		return fmt.Errorf("command %q: description is required", cmd.Name)
+	}
+
+	if len(cmd.Examples) == 0 {
+		return fmt.Errorf("command %q: at least one example is required", cmd.Name)
+	}
+
+	seen := make(map[string]bool, len(cmd.Params))
+	for _, p := range cmd.Params {
+		if !nameRegex.MatchString(p.Name) {
+			return fmt.Errorf("command %q: invalid parameter name %q", cmd.Name, p.Name)
+		}
+
+		if seen[p.Name] {
+			return fmt.Errorf("command %q: duplicate parameter %q", cmd.Name, p.Name)
+		}
+		seen[p.Name] = true
+
+		if !p.Type.valid() {
+			return fmt.Errorf("command %q: parameter %q has unsupported type %q", cmd.Name, p.Name, p.Type)
+		}
+
+		if p.Default != "" {
+			if _, err := p.Parse(p.Default); err != nil {
+				return fmt.Errorf("command %q: invalid default: %w", cmd.Name, err)
+			}
+		}
+	}
+
+	if _, ok := r.index[cmd.Name]; ok {
+		return fmt.Errorf("command %q: already registered", cmd.Name)
+	}
+
+	r.index[cmd.Name] = len(r.commands)
+	r.commands = append(r.commands, cmd)
+
+	return nil
+}
+
+// Handle sets the handler of a registered command.
+func (r *Registry) Handle(name string, handler HandlerFunc) error {
+	i, ok := r.index[name]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, name)
+	}
+
+	if handler == nil {
+		return fmt.Errorf("command %q: handler is required", name)
+	}
+
+	r.commands[i].Handler = handler
+
+	return nil
+}
+
+// Validate checks that every registered command has a handler.
+func (r *Registry) Validate() error {
+	var missing []string
+	for _, cmd := range r.commands {
+		if cmd.Handler == nil {
+			missing = append(missing, cmd.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %q", ErrNoHandler, missing)
+	}
+
+	return nil
+}
+
+// Lookup returns the command with the given name.
+func (r *Registry) Lookup(name string) (Command, bool) {
+	i, ok := r.index[name]
+	if !ok {
+		return Command{}, false
+	}
+
+	return r.commands[i], true
+}
+
+// Commands returns the registered commands.
+func (r *Registry) Commands() []Command {
+	commands := make([]Command, len(r.commands))
+	copy(commands, r.commands)
+
+	return commands
+}
+
+// Names returns the names of the registered commands.
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.commands))
+	for i, cmd := range r.commands {
+		names[i] = cmd.Name
+	}
+
+	return names
+}
+
+// Help returns a human readable line for each registered command.
+func (r *Registry) Help() []string {
+	lines := make([]string, len(r.commands))
+	for i, cmd := range r.commands {
+		lines[i] = fmt.Sprintf("%s (eg. %q)", cmd.Description, cmd.Examples[0])
+	}
+
+	return lines
+}
+
+// Parse validates the invocation and parses its arguments.
+func (r *Registry) Parse(inv Invocation) (Command, Values, error) {
+	cmd, ok := r.Lookup(inv.Name)
+	if !ok {
+		return Command{}, nil, fmt.Errorf("%w: %q", ErrUnknownCommand, inv.Name)
+	}
+
+	values, err := cmd.Parse(inv.Args)
+	if err != nil {
+		return Command{}, nil, err
+	}
+
+	return cmd, values, nil
+}
+
+// Dispatch validates the invocation and executes the command.
+func (r *Registry) Dispatch(ctx context.Context, inv Invocation) (string, error) {
+	cmd, values, err := r.Parse(inv)
+	if err != nil {
+		return "", err
+	}
+
+	if cmd.Handler == nil {
+		return "", fmt.Errorf("%w: %q", ErrNoHandler, inv.Name)
+	}
+
+	return cmd.Handler(ctx, values)
+}
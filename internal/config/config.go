@@ -0,0 +1,73 @@
+// Package config loads the config file, which declares the user-defined commands.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nizarmah/jarvis/internal/action"
+	"github.com/nizarmah/jarvis/internal/command"
+	"github.com/nizarmah/jarvis/internal/macro"
+)
+
+// Config is the config file.
+type Config struct {
+	// Actions are commands that run a program.
+	Actions []action.Config `json:"actions"`
+	// Macros are commands that run a sequence of commands, including the actions.
+	Macros []macro.Config `json:"macros"`
+}
+
+// Load reads the config file, an empty path is an empty config.
+func Load(path string) (*Config, error) {
+	var cfg Config
+	if path == "" {
+		return &cfg, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	// Catch typos, eg. `timout`, instead of silently ignoring them.
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode config file %q: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// Register registers the user-defined commands, after the builtins.
+// It returns the runner of the macros, to interrupt them.
+func (c *Config) Register(registry *command.Registry, debug bool) (*macro.Runner, error) {
+	for _, cfg := range c.Actions {
+		a, err := action.New(cfg, debug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create action: %w", err)
+		}
+
+		if err := registry.Register(a.Command()); err != nil {
+			return nil, fmt.Errorf("failed to register action: %w", err)
+		}
+	}
+
+	runner := macro.NewRunner(registry, debug)
+	for _, cfg := range c.Macros {
+		cmd, err := runner.Command(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create macro: %w", err)
+		}
+
+		if err := registry.Register(cmd); err != nil {
+			return nil, fmt.Errorf("failed to register macro: %w", err)
+		}
+	}
+
+	return runner, nil
+}
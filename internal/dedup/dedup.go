@@ -0,0 +1,161 @@
+// Package dedup provides a deduplicator for commands heard across overlapping audio windows.
+//
+// The combiner joins the previous and current chunks, so one spoken phrase
+// is usually transcribed twice, in full or in part, a chunk apart.
+package dedup
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// Config is the configuration for the deduplicator.
+type Config struct {
+	// Debug enables logging the suppressed utterances and commands.
+	Debug bool
+	// Similarity is the ratio of shared words above which two utterances are the same, in (0, 1].
+	Similarity float64
+	// Window is how long an executed command suppresses its repeats.
+	Window time.Duration
+}
+
+// Deduplicator suppresses repeated utterances and commands within a window.
+type Deduplicator struct {
+	debug      bool
+	similarity float64
+	window     time.Duration
+
+	mu     sync.Mutex
+	recent []entry
+}
+
+// entry is an executed command and the utterance it was interpreted from.
+type entry struct {
+	words []string
+	inv   command.Invocation
+	at    time.Time
+}
+
+// NewDeduplicator creates a new deduplicator.
+func NewDeduplicator(cfg Config) (*Deduplicator, error) {
+	if cfg.Similarity <= 0 || cfg.Similarity > 1 {
+		return nil, fmt.Errorf("similarity must be in (0, 1]")
+	}
+
+	if cfg.Window < 0 {
+		return nil, fmt.Errorf("window must not be negative")
+	}
+
+	return &Deduplicator{
+		debug:      cfg.Debug,
+		similarity: cfg.Similarity,
+		window:     cfg.Window,
+	}, nil
+}
+
+// SeenUtterance checks if the utterance is similar to one that executed a command within the window.
+// It is cheap, so it runs before interpreting the utterance.
+func (d *Deduplicator) SeenUtterance(utterance string, at time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.prune(at)
+
+	words := splitWords(utterance)
+	for _, e := range d.recent {
+		if similarity(words, e.words) >= d.similarity {
+			if d.debug {
+				log.Println(fmt.Sprintf("suppressed utterance %q: similar to %q", utterance, strings.Join(e.words, " ")))
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// SeenCommand checks if the same command with the same arguments was executed within the window.
+func (d *Deduplicator) SeenCommand(inv command.Invocation, at time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.prune(at)
+
+	for _, e := range d.recent {
+		if e.inv.Name == inv.Name && maps.Equal(e.inv.Args, inv.Args) {
+			if d.debug {
+				log.Println(fmt.Sprintf("suppressed command %q: executed %s ago", inv, at.Sub(e.at).Round(time.Millisecond)))
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// Record records the executed command and the utterance it was interpreted from.
+func (d *Deduplicator) Record(utterance string, inv command.Invocation, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.recent = append(d.recent, entry{
+		words: splitWords(utterance),
+		inv:   inv,
+		at:    at,
+	})
+}
+
+// prune removes the entries older than the window.
+func (d *Deduplicator) prune(now time.Time) {
+	kept := d.recent[:0]
+	for _, e := range d.recent {
+		if now.Sub(e.at) <= d.window {
+			kept = append(kept, e)
+		}
+	}
+
+	d.recent = kept
+}
+
+// similarity returns the ratio of words of the shorter utterance found in the other one.
+// A partial transcript of an overlapping window is mostly contained in the full one.
+func similarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+
+	counts := make(map[string]int, len(b))
+	for _, w := range b {
+		counts[w]++
+	}
+
+	shared := 0
+	for _, w := range a {
+		if counts[w] > 0 {
+			counts[w]--
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(a))
+}
+
+// splitWords splits the text into lowercase words, without punctuation.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
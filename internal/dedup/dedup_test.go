@@ -0,0 +1,148 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// newTestDeduplicator creates a deduplicator with the example.env settings.
+func newTestDeduplicator(t *testing.T) *Deduplicator {
+	t.Helper()
+
+	d, err := NewDeduplicator(Config{Similarity: 0.8, Window: 3 * time.Second})
+	if err != nil {
+		t.Fatalf("NewDeduplicator() failed: %v", err)
+	}
+
+	return d
+}
+
+func TestSeenUtterance(t *testing.T) {
+	recorded := "Jarvis, pause the video now."
+
+	tests := []struct {
+		name      string
+		utterance string
+		after     time.Duration
+		want      bool
+	}{
+		{name: "same utterance", utterance: recorded, after: time.Second, want: true},
+		{name: "same utterance inside the window", utterance: recorded, after: 2900 * time.Millisecond, want: true},
+		{name: "same utterance at the window", utterance: recorded, after: 3 * time.Second, want: true},
+		{name: "same utterance outside the window", utterance: recorded, after: 3100 * time.Millisecond},
+		{name: "case and punctuation", utterance: "jarvis pause the video now", after: time.Second, want: true},
+		{name: "partial transcript", utterance: "pause the video", after: time.Second, want: true},
+		{name: "similarity at the threshold", utterance: "jarvis pause the video later", after: time.Second, want: true},
+		{name: "similarity under the threshold", utterance: "jarvis pause the music", after: time.Second},
+		{name: "different utterance", utterance: "Jarvis, next track.", after: time.Second},
+		{name: "empty utterance", utterance: "", after: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDeduplicator(t)
+			now := time.Now()
+
+			d.Record(recorded, command.Invocation{Name: "pause_video"}, now)
+
+			if got := d.SeenUtterance(tt.utterance, now.Add(tt.after)); got != tt.want {
+				t.Errorf("SeenUtterance(%q) after %s = %t, want %t", tt.utterance, tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeenCommand(t *testing.T) {
+	recorded := command.Invocation{Name: "set_volume", Args: command.Args{"level": "40"}}
+
+	tests := []struct {
+		name  string
+		inv   command.Invocation
+		after time.Duration
+		want  bool
+	}{
+		{name: "same command", inv: recorded, after: time.Second, want: true},
+		{name: "same command inside the window", inv: recorded, after: 2900 * time.Millisecond, want: true},
+		{name: "same command outside the window", inv: recorded, after: 3100 * time.Millisecond},
+		{name: "different args", inv: command.Invocation{Name: "set_volume", Args: command.Args{"level": "60"}}, after: time.Second},
+		{name: "extra args", inv: command.Invocation{Name: "set_volume", Args: command.Args{"level": "40", "player": "vlc"}}, after: time.Second},
+		{name: "no args", inv: command.Invocation{Name: "set_volume"}, after: time.Second},
+		{name: "different command", inv: command.Invocation{Name: "mute", Args: command.Args{"level": "40"}}, after: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestDeduplicator(t)
+			now := time.Now()
+
+			d.Record("jarvis set the volume to 40", recorded, now)
+
+			if got := d.SeenCommand(tt.inv, now.Add(tt.after)); got != tt.want {
+				t.Errorf("SeenCommand(%s) after %s = %t, want %t", tt.inv, tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordPrunes(t *testing.T) {
+	d := newTestDeduplicator(t)
+	now := time.Now()
+
+	d.Record("jarvis pause", command.Invocation{Name: "pause_video"}, now)
+	d.Record("jarvis play", command.Invocation{Name: "play_video"}, now.Add(2*time.Second))
+
+	// The first command expires, the second one is still within its window.
+	at := now.Add(4 * time.Second)
+	if d.SeenCommand(command.Invocation{Name: "pause_video"}, at) {
+		t.Errorf("SeenCommand(pause_video) = true after its window, want false")
+	}
+
+	if !d.SeenCommand(command.Invocation{Name: "play_video"}, at) {
+		t.Errorf("SeenCommand(play_video) = false inside its window, want true")
+	}
+
+	if len(d.recent) != 1 {
+		t.Errorf("recent has %d entries, want 1", len(d.recent))
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "pause the video", b: "pause the video", want: 1},
+		{a: "pause the video", b: "jarvis pause the video now", want: 1},
+		{a: "pause the music", b: "pause the video", want: 2.0 / 3},
+		{a: "go go go", b: "go", want: 1},
+		{a: "go go", b: "go back", want: 0.5},
+		{a: "", b: "pause", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := similarity(splitWords(tt.a), splitWords(tt.b)); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNewDeduplicatorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "no similarity", cfg: Config{Similarity: 0, Window: time.Second}},
+		{name: "similarity over one", cfg: Config{Similarity: 1.1, Window: time.Second}},
+		{name: "negative window", cfg: Config{Similarity: 0.8, Window: -time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDeduplicator(tt.cfg); err == nil {
+				t.Errorf("NewDeduplicator(%+v) succeeded, want an error", tt.cfg)
+			}
+		})
+	}
+}
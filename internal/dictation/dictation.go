@@ -0,0 +1,256 @@
+// Package dictation provides the dictation mode, which types speech into the focused window instead of interpreting it.
+package dictation
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// Commands of the executor.
+const (
+	// TypeCommand types the text with spoken punctuation.
+	TypeCommand = "type_text"
+	// UndoCommand deletes the last typed text.
+	UndoCommand = "undo_dictation"
+)
+
+// typeWord types the rest of a single instruction, eg. `type hello world`.
+const typeWord = "type"
+
+// Config is the configuration for the dictation mode.
+type Config struct {
+	// Debug enables logging the mode changes.
+	Debug bool
+	// StartPhrases start the dictation mode, eg. `start dictation`.
+	StartPhrases []string
+	// EndPhrases end the dictation mode, eg. `stop dictation`.
+	EndPhrases []string
+	// UndoPhrases undo the last typed text, eg. `scratch that`.
+	UndoPhrases []string
+	// IdleTimeout ends the dictation mode after no speech for a while, zero never ends it.
+	IdleTimeout time.Duration
+}
+
+// Mode tracks whether the listener is dictating.
+type Mode struct {
+	debug        bool
+	startPhrases []string
+	endPhrases   []string
+	undoPhrases  []string
+	idleTimeout  time.Duration
+
+	mu       sync.Mutex
+	active   bool
+	lastSeen time.Time
+	// previous are the words of the previous audio window, to skip the words repeated by the overlap.
+	previous []string
+}
+
+// NewMode creates a new dictation mode.
+func NewMode(cfg Config) (*Mode, error) {
+	if len(cfg.StartPhrases) == 0 || len(cfg.EndPhrases) == 0 {
+		return nil, fmt.Errorf("start and end phrases are required")
+	}
+
+	if cfg.IdleTimeout < 0 {
+		return nil, fmt.Errorf("idle timeout must not be negative")
+	}
+
+	return &Mode{
+		debug:        cfg.Debug,
+		startPhrases: normalizePhrases(cfg.StartPhrases),
+		endPhrases:   normalizePhrases(cfg.EndPhrases),
+		undoPhrases:  normalizePhrases(cfg.UndoPhrases),
+		idleTimeout:  cfg.IdleTimeout,
+	}, nil
+}
+
+// Active checks if the dictation mode is on at the time, ending it if it was idle for too long.
+func (m *Mode) Active(at time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.active && m.idleTimeout > 0 && at.Sub(m.lastSeen) > m.idleTimeout {
+		m.stop("idle")
+	}
+
+	return m.active
+}
+
+// Instruction handles an instruction addressed to Jarvis, outside of the dictation mode.
+// It returns the command to execute, if any, and whether the instruction was a dictation one.
+func (m *Mode) Instruction(instruction string, at time.Time) (command.Invocation, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	words := splitWords(instruction)
+
+	// Type a single phrase, eg. `type on my way`.
+	if len(words.norm) > 1 && words.norm[0] == typeWord {
+		return typeInvocation(words.rawText(1)), true
+	}
+
+	if rest, ok := words.cutPhrase(m.startPhrases); ok {
+		m.active = true
+		m.lastSeen = at
+		m.previous = words.norm
+
+		if m.debug {
+			log.Println("dictation started")
+		}
+
+		// Type what was said after the start phrase in the same breath.
+		if rest != "" {
+			return typeInvocation(rest), true
+		}
+
+		return command.Invocation{}, true
+	}
+
+	// The end and undo phrases mean nothing outside of the dictation mode,
+	// but they are likely repeats of the previous audio window.
+	if _, ok := words.cutPhrase(slices.Concat(m.endPhrases, m.undoPhrases)); ok {
+		return command.Invocation{}, true
+	}
+
+	return command.Invocation{}, false
+}
+
+// Transcript handles a transcript in the dictation mode, without interpreting it.
+// It returns the command to execute, if any.
+func (m *Mode) Transcript(transcript string, at time.Time) (command.Invocation, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastSeen = at
+
+	words := splitWords(transcript)
+	previous := m.previous
+	m.previous = words.norm
+
+	if _, ok := words.cutPhrase(m.endPhrases); ok {
+		m.stop("end phrase")
+		return command.Invocation{}, false
+	}
+
+	// Skip the words repeated from the previous audio window,
+	// so a phrase that overlaps both windows is neither typed nor undone twice.
+	fresh := words.from(overlap(previous, words.norm))
+	if len(fresh.norm) == 0 {
+		return command.Invocation{}, false
+	}
+
+	if _, ok := fresh.cutPhrase(m.undoPhrases); ok {
+		return command.Invocation{Name: UndoCommand, Args: command.Args{}}, true
+	}
+
+	// Drop the start phrase repeated by the overlap, and the wake word before it.
+	text := fresh.rawText(0)
+	if rest, ok := fresh.cutPhrase(m.startPhrases); ok {
+		text = rest
+	}
+
+	if text == "" {
+		return command.Invocation{}, false
+	}
+
+	return typeInvocation(text), true
+}
+
+// stop ends the dictation mode.
+func (m *Mode) stop(reason string) {
+	m.active = false
+	m.previous = nil
+
+	if m.debug {
+		log.Println(fmt.Sprintf("dictation ended: %s", reason))
+	}
+}
+
+// typeInvocation returns the command that types the text.
+func typeInvocation(text string) command.Invocation {
+	return command.Invocation{
+		Name: TypeCommand,
+		Args: command.Args{"text": strings.TrimSpace(text)},
+	}
+}
+
+// words are the words of a transcript, as spoken and normalized.
+type words struct {
+	// raw are the words with their case and punctuation, eg. `Hello,`.
+	raw []string
+	// norm are the lowercase words without punctuation, eg. `hello`.
+	norm []string
+}
+
+// splitWords splits the text into words, dropping the punctuation-only ones, eg. `-`.
+func splitWords(text string) words {
+	var w words
+	for _, field := range strings.Fields(text) {
+		norm := strings.TrimFunc(strings.ToLower(field), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+		})
+
+		if norm != "" {
+			w.raw = append(w.raw, field)
+			w.norm = append(w.norm, norm)
+		}
+	}
+
+	return w
+}
+
+// from returns the words starting at the index.
+func (w words) from(i int) words {
+	return words{raw: w.raw[i:], norm: w.norm[i:]}
+}
+
+// rawText joins the raw words starting at the index.
+func (w words) rawText(i int) string {
+	return strings.Join(w.raw[i:], " ")
+}
+
+// cutPhrase finds the first of the phrases in the words.
+// It returns the raw text after the phrase.
+func (w words) cutPhrase(phrases []string) (string, bool) {
+	for _, phrase := range phrases {
+		phraseWords := strings.Fields(phrase)
+		for i := 0; i+len(phraseWords) <= len(w.norm); i++ {
+			if slices.Equal(w.norm[i:i+len(phraseWords)], phraseWords) {
+				return w.rawText(i + len(phraseWords)), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// overlap returns the number of words at the start of next that repeat the end of previous.
+func overlap(previous, next []string) int {
+	for k := min(len(previous), len(next)); k > 0; k-- {
+		if slices.Equal(previous[len(previous)-k:], next[:k]) {
+			return k
+		}
+	}
+
+	return 0
+}
+
+// normalizePhrases lowercases the phrases and strips their punctuation.
+func normalizePhrases(phrases []string) []string {
+	normalized := make([]string, 0, len(phrases))
+	for _, phrase := range phrases {
+		if w := splitWords(phrase); len(w.norm) > 0 {
+			normalized = append(normalized, strings.Join(w.norm, " "))
+		}
+	}
+
+	return normalized
+}
@@ -0,0 +1,205 @@
+package dictation
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxHistory is the number of typed texts that can be undone.
+const maxHistory = 50
+
+// punctuation maps the spoken punctuation to its symbol, longest phrases first.
+var punctuation = []struct {
+	spoken string
+	symbol string
+}{
+	{"new paragraph", "\n\n"},
+	{"new line", "\n"},
+	{"question mark", "?"},
+	{"exclamation mark", "!"},
+	{"exclamation point", "!"},
+	{"full stop", "."},
+	{"semicolon", ";"},
+	{"period", "."},
+	{"comma", ","},
+	{"colon", ":"},
+}
+
+// Keyboard types text and taps keys, eg. an actuator.
+// It keeps the listener, which shares this package, free of the actuator dependencies.
+type Keyboard interface {
+	KeyTap(key string, modifiers ...string) error
+	Type(text string) error
+}
+
+// Typist types dictated text into the focused window and remembers it to undo it.
+type Typist struct {
+	keyboard Keyboard
+	debug    bool
+
+	mu      sync.Mutex
+	history []string
+}
+
+// NewTypist creates a new typist.
+func NewTypist(keyboard Keyboard, debug bool) *Typist {
+	return &Typist{
+		keyboard: keyboard,
+		debug:    debug,
+	}
+}
+
+// Type types the text, replacing the spoken punctuation, eg. `comma`, and continuing the previous text.
+// It returns the typed text.
+func (t *Typist) Type(text string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var previous string
+	if len(t.history) > 0 {
+		previous = t.history[len(t.history)-1]
+	}
+
+	typed := format(text, previous)
+	if typed == "" {
+		return "", nil
+	}
+
+	if err := t.keyboard.Type(typed); err != nil {
+		return "", fmt.Errorf("failed to type text: %w", err)
+	}
+
+	t.history = append(t.history, typed)
+	if len(t.history) > maxHistory {
+		t.history = t.history[1:]
+	}
+
+	if t.debug {
+		log.Println(fmt.Sprintf("typed: %q", typed))
+	}
+
+	return typed, nil
+}
+
+// Undo deletes the last typed text with backspaces.
+// It returns the deleted text.
+func (t *Typist) Undo() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.history) == 0 {
+		return "", fmt.Errorf("nothing to undo")
+	}
+
+	last := t.history[len(t.history)-1]
+	for range utf8.RuneCountInString(last) {
+		if err := t.keyboard.KeyTap("backspace"); err != nil {
+			return "", fmt.Errorf("failed to delete text: %w", err)
+		}
+	}
+
+	t.history = t.history[:len(t.history)-1]
+
+	if t.debug {
+		log.Println(fmt.Sprintf("deleted: %q", last))
+	}
+
+	return last, nil
+}
+
+// format replaces the spoken punctuation of the text, and spaces and capitalizes it after the previous text.
+func format(text, previous string) string {
+	var b strings.Builder
+
+	// A sentence starts at the beginning, or after a sentence ends.
+	sentenceStart := previous == "" || strings.HasSuffix(strings.TrimRight(previous, " "), "\n") ||
+		strings.ContainsAny(lastRune(strings.TrimRight(previous, " ")), ".?!")
+	needSpace := previous != "" && !strings.HasSuffix(previous, "\n") && !strings.HasSuffix(previous, " ")
+
+	// afterSymbol is whether the last token was spoken punctuation, eg. `period` in `period new line`.
+	afterSymbol := false
+
+	words := strings.Fields(text)
+	for i := 0; i < len(words); i++ {
+		if symbol, n := matchPunctuation(words[i:]); n > 0 {
+			// Whisper may also punctuate the word before, eg. `hello, comma`, but not the symbol before.
+			if !afterSymbol {
+				trimmed := strings.TrimRightFunc(b.String(), unicode.IsPunct)
+				b.Reset()
+				b.WriteString(trimmed)
+			}
+
+			b.WriteString(symbol)
+
+			i += n - 1
+			needSpace = !strings.HasSuffix(symbol, "\n")
+			sentenceStart = strings.ContainsAny(symbol, ".?!\n")
+			afterSymbol = true
+
+			continue
+		}
+
+		afterSymbol = false
+
+		word := words[i]
+		if sentenceStart || word == "i" || strings.HasPrefix(word, "i'") {
+			word = capitalize(word)
+		}
+
+		if needSpace {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(word)
+
+		needSpace = true
+		sentenceStart = strings.ContainsAny(lastRune(word), ".?!")
+	}
+
+	return b.String()
+}
+
+// matchPunctuation matches the spoken punctuation at the start of the words.
+// It returns the symbol and the number of words it spans.
+func matchPunctuation(words []string) (string, int) {
+	for _, p := range punctuation {
+		spoken := strings.Fields(p.spoken)
+		if len(spoken) > len(words) {
+			continue
+		}
+
+		matched := true
+		for i, s := range spoken {
+			if strings.TrimFunc(strings.ToLower(words[i]), unicode.IsPunct) != s {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return p.symbol, len(spoken)
+		}
+	}
+
+	return "", 0
+}
+
+// capitalize uppercases the first letter of the word.
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
+
+// lastRune returns the last rune of the text, or empty.
+func lastRune(text string) string {
+	r, size := utf8.DecodeLastRuneInString(text)
+	if size == 0 {
+		return ""
+	}
+
+	return string(r)
+}
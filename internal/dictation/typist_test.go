@@ -0,0 +1,113 @@
+package dictation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeKeyboard records the typed text and the tapped keys.
+type fakeKeyboard struct {
+	typed []string
+	taps  []string
+}
+
+func (k *fakeKeyboard) KeyTap(key string, modifiers ...string) error {
+	k.taps = append(k.taps, strings.Join(append(slices.Clone(modifiers), key), "+"))
+	return nil
+}
+
+func (k *fakeKeyboard) Type(text string) error {
+	k.typed = append(k.typed, text)
+	return nil
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		previous string
+		want     string
+	}{
+		{name: "capitalizes the start", text: "hello world", want: "Hello world"},
+		{name: "spoken comma", text: "on my way comma see you soon", want: "On my way, see you soon"},
+		{name: "whisper punctuation before the symbol", text: "hello, comma world", want: "Hello, world"},
+		{name: "whisper punctuation on the symbol", text: "hello period.", want: "Hello."},
+		{name: "capitalizes after period", text: "done period next one", want: "Done. Next one"},
+		{name: "capitalizes after question mark", text: "ready question mark yes", want: "Ready? Yes"},
+		{name: "period then new line", text: "hello period new line", want: "Hello.\n"},
+		{name: "question mark then new paragraph", text: "are you there question mark new paragraph", want: "Are you there?\n\n"},
+		{name: "exclamation point then new line", text: "wow exclamation point new line great", want: "Wow!\nGreat"},
+		{name: "whisper punctuation then two symbols", text: "hello. period new line", want: "Hello.\n"},
+		{name: "capitalizes i", text: "i think i'm late", want: "I think I'm late"},
+		{name: "continues the previous text", text: "and more", previous: "Hello", want: " and more"},
+		{name: "capitalizes after the previous sentence", text: "next", previous: "Done.", want: " Next"},
+		{name: "no space after the previous line", text: "next", previous: "Done.\n", want: "Next"},
+		{name: "only punctuation", text: "comma", previous: "Hello", want: ","},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := format(tt.text, tt.previous); got != tt.want {
+				t.Errorf("format(%q, %q) = %q, want %q", tt.text, tt.previous, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypistContinuesAndUndoes(t *testing.T) {
+	keyboard := &fakeKeyboard{}
+	typist := NewTypist(keyboard, false)
+
+	for _, text := range []string{"hello period", "how are you question mark", "fine"} {
+		if _, err := typist.Type(text); err != nil {
+			t.Fatalf("Type(%q) failed: %v", text, err)
+		}
+	}
+
+	want := []string{"Hello.", " How are you?", " Fine"}
+	if !slices.Equal(keyboard.typed, want) {
+		t.Errorf("typed = %q, want %q", keyboard.typed, want)
+	}
+
+	// Undo deletes the last typed text, one backspace per character.
+	deleted, err := typist.Undo()
+	if err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+
+	if deleted != " Fine" || !slices.Equal(keyboard.taps, slices.Repeat([]string{"backspace"}, 5)) {
+		t.Errorf("Undo() = %q with taps %q, want %q with 5 backspaces", deleted, keyboard.taps, " Fine")
+	}
+
+	// The next text continues the text before the undone one.
+	typed, err := typist.Type("good")
+	if err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+
+	if typed != " Good" {
+		t.Errorf("Type() after Undo() = %q, want %q", typed, " Good")
+	}
+}
+
+func TestTypistUndoCountsRunes(t *testing.T) {
+	keyboard := &fakeKeyboard{}
+	typist := NewTypist(keyboard, false)
+
+	if _, err := typist.Type("café new line"); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+
+	if _, err := typist.Undo(); err != nil {
+		t.Fatalf("Undo() failed: %v", err)
+	}
+
+	if got := len(keyboard.taps); got != 5 {
+		t.Errorf("Undo() tapped %d backspaces, want 5", got)
+	}
+
+	if _, err := typist.Undo(); err == nil {
+		t.Errorf("Undo() with nothing typed succeeded, want an error")
+	}
+}
@@ -5,28 +5,86 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Env holds relevant env variables.
 type Env struct {
-	AudioProcessorDebug bool
-	CommandDebug        bool
-	CombinerDebug       bool
-	CombinerOutputDir   string
-	ExecutorAddress     string
-	ExecutorDebug       bool
-	MessageHandlerDebug bool
-	OllamaDebug         bool
-	OllamaModel         string
-	OllamaURL           string
-	RecorderChunkNum    int
-	RecorderChunkSize   int
-	RecorderDebug       bool
-	RecorderOutputDir   string
-	WhisperDebug        bool
-	WhisperModel        string
-	WhisperLanguage     string
-	WhisperOutputDir    string
+	ActuatorBackend                  string
+	ActuatorDebug                    bool
+	AudioProcessorDebug              bool
+	CommandDebug                     bool
+	CombinerDebug                    bool
+	CombinerOutputDir                string
+	ConfigFile                       string
+	DedupDebug                       bool
+	DedupSimilarity                  float64
+	DedupWindow                      time.Duration
+	DictationDebug                   bool
+	DictationEndPhrases              []string
+	DictationIdleTimeout             time.Duration
+	DictationStartPhrases            []string
+	DictationUndoPhrases             []string
+	ExecutorAddress                  string
+	ExecutorDebug                    bool
+	ExecutorRetries                  int
+	ExecutorRetryDelay               time.Duration
+	HallucinationBlocklist           []string
+	HallucinationDebug               bool
+	HallucinationMaxCompressionRatio float64
+	HallucinationMaxNoSpeechProb     float64
+	HallucinationMaxRepeats          int
+	HallucinationMinAvgLogprob       float64
+	IntentDebug                      bool
+	IntentMinConfidence              float64
+	KeymapDebug                      bool
+	KeymapDefault                    string
+	KeymapProfile                    string
+	MessageHandlerDebug              bool
+	MprisDebug                       bool
+	MprisEnabled                     bool
+	MprisPlayer                      string
+	OllamaDebug                      bool
+	OllamaKeepAlive                  time.Duration
+	OllamaModel                      string
+	OllamaNumPredict                 int
+	OllamaRetries                    int
+	OllamaRetryDelay                 time.Duration
+	OllamaSeed                       int
+	OllamaStop                       []string
+	OllamaTemperature                float64
+	OllamaTimeout                    time.Duration
+	OllamaURL                        string
+	RecorderChunkNum                 int
+	RecorderChunkSize                int
+	RecorderDebug                    bool
+	RecorderOutputDir                string
+	SchedulerDebug                   bool
+	SchedulerFile                    string
+	SchedulerMaxLate                 time.Duration
+	SkipAdPosition                   []int
+	TimerAlarmBackend                string
+	TimerAlarmBinary                 string
+	TimerAlarmOutput                 string
+	TimerAlarmSound                  string
+	TimerAnnouncer                   string
+	TimerDebug                       bool
+	TimerRingDuration                time.Duration
+	WakeWordAliases                  []string
+	WakeWordAlwaysListening          bool
+	WakeWordDebug                    bool
+	WakeWordMaxDistance              int
+	WakeWordPhrases                  []string
+	WhisperAPIKey                    string
+	WhisperBackend                   string
+	WhisperBinary                    string
+	WhisperDebug                     bool
+	WhisperModel                     string
+	WhisperLanguage                  string
+	WhisperOutputDir                 string
+	WhisperTimeout                   time.Duration
+	WhisperURL                       string
 }
 
 // Init reads env vars.
@@ -36,6 +94,16 @@ func Init() (*Env, error) {
 		err error
 	)
 
+	env.ActuatorBackend, err = lookup("ACTUATOR_BACKEND")
+	if err != nil {
+		return nil, err
+	}
+
+	env.ActuatorDebug, err = lookupBool("ACTUATOR_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
 	env.AudioProcessorDebug, err = lookupBool("AUDIO_PROCESSOR_DEBUG")
 	if err != nil {
 		return nil, err
@@ -56,6 +124,51 @@ func Init() (*Env, error) {
 		return nil, err
 	}
 
+	env.ConfigFile, err = lookup("CONFIG_FILE")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DedupDebug, err = lookupBool("DEDUP_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DedupSimilarity, err = lookupFloat("DEDUP_SIMILARITY")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DedupWindow, err = lookupDuration("DEDUP_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DictationDebug, err = lookupBool("DICTATION_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DictationEndPhrases, err = lookupList("DICTATION_END_PHRASES")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DictationIdleTimeout, err = lookupDuration("DICTATION_IDLE_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DictationStartPhrases, err = lookupList("DICTATION_START_PHRASES")
+	if err != nil {
+		return nil, err
+	}
+
+	env.DictationUndoPhrases, err = lookupList("DICTATION_UNDO_PHRASES")
+	if err != nil {
+		return nil, err
+	}
+
 	env.ExecutorAddress, err = lookup("EXECUTOR_ADDRESS")
 	if err != nil {
 		return nil, err
@@ -66,21 +179,141 @@ func Init() (*Env, error) {
 		return nil, err
 	}
 
+	env.ExecutorRetries, err = lookupInt("EXECUTOR_RETRIES")
+	if err != nil {
+		return nil, err
+	}
+
+	env.ExecutorRetryDelay, err = lookupDuration("EXECUTOR_RETRY_DELAY")
+	if err != nil {
+		return nil, err
+	}
+
+	env.HallucinationBlocklist, err = lookupList("HALLUCINATION_BLOCKLIST")
+	if err != nil {
+		return nil, err
+	}
+
+	env.HallucinationDebug, err = lookupBool("HALLUCINATION_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.HallucinationMaxCompressionRatio, err = lookupFloat("HALLUCINATION_MAX_COMPRESSION_RATIO")
+	if err != nil {
+		return nil, err
+	}
+
+	env.HallucinationMaxNoSpeechProb, err = lookupFloat("HALLUCINATION_MAX_NO_SPEECH_PROB")
+	if err != nil {
+		return nil, err
+	}
+
+	env.HallucinationMaxRepeats, err = lookupInt("HALLUCINATION_MAX_REPEATS")
+	if err != nil {
+		return nil, err
+	}
+
+	env.HallucinationMinAvgLogprob, err = lookupFloat("HALLUCINATION_MIN_AVG_LOGPROB")
+	if err != nil {
+		return nil, err
+	}
+
+	env.IntentDebug, err = lookupBool("INTENT_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.IntentMinConfidence, err = lookupFloat("INTENT_MIN_CONFIDENCE")
+	if err != nil {
+		return nil, err
+	}
+
+	env.KeymapDebug, err = lookupBool("KEYMAP_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.KeymapDefault, err = lookup("KEYMAP_DEFAULT")
+	if err != nil {
+		return nil, err
+	}
+
+	env.KeymapProfile, err = lookup("KEYMAP_PROFILE")
+	if err != nil {
+		return nil, err
+	}
+
 	env.MessageHandlerDebug, err = lookupBool("MESSAGE_HANDLER_DEBUG")
 	if err != nil {
 		return nil, err
 	}
 
+	env.MprisDebug, err = lookupBool("MPRIS_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.MprisEnabled, err = lookupBool("MPRIS_ENABLED")
+	if err != nil {
+		return nil, err
+	}
+
+	env.MprisPlayer, err = lookup("MPRIS_PLAYER")
+	if err != nil {
+		return nil, err
+	}
+
 	env.OllamaDebug, err = lookupBool("OLLAMA_DEBUG")
 	if err != nil {
 		return nil, err
 	}
 
+	env.OllamaKeepAlive, err = lookupDuration("OLLAMA_KEEP_ALIVE")
+	if err != nil {
+		return nil, err
+	}
+
 	env.OllamaModel, err = lookup("OLLAMA_MODEL")
 	if err != nil {
 		return nil, err
 	}
 
+	env.OllamaNumPredict, err = lookupInt("OLLAMA_NUM_PREDICT")
+	if err != nil {
+		return nil, err
+	}
+
+	env.OllamaRetries, err = lookupInt("OLLAMA_RETRIES")
+	if err != nil {
+		return nil, err
+	}
+
+	env.OllamaRetryDelay, err = lookupDuration("OLLAMA_RETRY_DELAY")
+	if err != nil {
+		return nil, err
+	}
+
+	env.OllamaSeed, err = lookupInt("OLLAMA_SEED")
+	if err != nil {
+		return nil, err
+	}
+
+	env.OllamaStop, err = lookupList("OLLAMA_STOP")
+	if err != nil {
+		return nil, err
+	}
+
+	env.OllamaTemperature, err = lookupFloat("OLLAMA_TEMPERATURE")
+	if err != nil {
+		return nil, err
+	}
+
+	env.OllamaTimeout, err = lookupDuration("OLLAMA_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+
 	env.OllamaURL, err = lookup("OLLAMA_URL")
 	if err != nil {
 		return nil, err
@@ -106,6 +339,101 @@ func Init() (*Env, error) {
 		return nil, err
 	}
 
+	env.SchedulerDebug, err = lookupBool("SCHEDULER_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.SchedulerFile, err = lookup("SCHEDULER_FILE")
+	if err != nil {
+		return nil, err
+	}
+
+	env.SchedulerMaxLate, err = lookupDuration("SCHEDULER_MAX_LATE")
+	if err != nil {
+		return nil, err
+	}
+
+	env.SkipAdPosition, err = lookupIntList("SKIP_AD_POSITION")
+	if err != nil {
+		return nil, err
+	}
+
+	env.TimerAlarmBackend, err = lookup("TIMER_ALARM_BACKEND")
+	if err != nil {
+		return nil, err
+	}
+
+	env.TimerAlarmBinary, err = lookup("TIMER_ALARM_BINARY")
+	if err != nil {
+		return nil, err
+	}
+
+	env.TimerAlarmOutput, err = lookup("TIMER_ALARM_OUTPUT")
+	if err != nil {
+		return nil, err
+	}
+
+	env.TimerAlarmSound, err = lookup("TIMER_ALARM_SOUND")
+	if err != nil {
+		return nil, err
+	}
+
+	env.TimerAnnouncer, err = lookup("TIMER_ANNOUNCER")
+	if err != nil {
+		return nil, err
+	}
+
+	env.TimerDebug, err = lookupBool("TIMER_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.TimerRingDuration, err = lookupDuration("TIMER_RING_DURATION")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WakeWordAliases, err = lookupList("WAKE_WORD_ALIASES")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WakeWordAlwaysListening, err = lookupBool("WAKE_WORD_ALWAYS_LISTENING")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WakeWordDebug, err = lookupBool("WAKE_WORD_DEBUG")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WakeWordMaxDistance, err = lookupInt("WAKE_WORD_MAX_DISTANCE")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WakeWordPhrases, err = lookupList("WAKE_WORD_PHRASES")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WhisperAPIKey, err = lookup("WHISPER_API_KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WhisperBackend, err = lookup("WHISPER_BACKEND")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WhisperBinary, err = lookup("WHISPER_BINARY")
+	if err != nil {
+		return nil, err
+	}
+
 	env.WhisperDebug, err = lookupBool("WHISPER_DEBUG")
 	if err != nil {
 		return nil, err
@@ -126,6 +454,16 @@ func Init() (*Env, error) {
 		return nil, err
 	}
 
+	env.WhisperTimeout, err = lookupDuration("WHISPER_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+
+	env.WhisperURL, err = lookup("WHISPER_URL")
+	if err != nil {
+		return nil, err
+	}
+
 	return &env, nil
 }
 
@@ -158,3 +496,57 @@ func lookupInt(s string) (int, error) {
 
 	return strconv.Atoi(value)
 }
+
+// lookupDuration helps verifying an env var exists and casts its value as duration.
+func lookupDuration(s string) (time.Duration, error) {
+	value, err := lookup(s)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.ParseDuration(value)
+}
+
+// lookupFloat helps verifying an env var exists and casts its value as float.
+func lookupFloat(s string) (float64, error) {
+	value, err := lookup(s)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
+
+// lookupList helps verifying an env var exists and splits its comma-separated value.
+func lookupList(s string) ([]string, error) {
+	value, err := lookup(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list, nil
+}
+
+// lookupIntList helps verifying an env var exists and casts its comma-separated value as ints.
+func lookupIntList(s string) ([]int, error) {
+	list, err := lookupList(s)
+	if err != nil {
+		return nil, err
+	}
+
+	ints := make([]int, len(list))
+	for i, item := range list {
+		if ints[i], err = strconv.Atoi(item); err != nil {
+			return nil, fmt.Errorf("env var %s: %w", s, err)
+		}
+	}
+
+	return ints, nil
+}
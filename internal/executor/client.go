@@ -2,28 +2,55 @@
 package executor
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"net"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+	"github.com/nizarmah/jarvis/internal/protocol"
 )
 
 // ClientConfig is the configuration for the client.
 type ClientConfig struct {
 	Address string
 	Debug   bool
+	// Retries is the number of retries when the executor is unavailable.
+	Retries int
+	// RetryDelay is the delay before the first retry, doubled on each retry.
+	RetryDelay time.Duration
+	// Source identifies the client in the requests, eg. `listener`.
+	Source string
 }
 
 // Client is the client for the executor server.
 type Client struct {
-	address string
-	debug   bool
+	address    string
+	debug      bool
+	retries    int
+	retryDelay time.Duration
+	source     string
 }
 
 // NewClient creates a new client.
 func NewClient(cfg ClientConfig) (*Client, error) {
+	if cfg.Source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+
+	if cfg.Retries < 0 {
+		return nil, fmt.Errorf("retries must be positive")
+	}
+
 	client := &Client{
-		address: cfg.Address,
-		debug:   cfg.Debug,
+		address:    cfg.Address,
+		debug:      cfg.Debug,
+		retries:    cfg.Retries,
+		retryDelay: cfg.RetryDelay,
+		source:     cfg.Source,
 	}
 
 	if err := client.Healthcheck(context.Background()); err != nil {
@@ -46,21 +73,87 @@ func (c *Client) Healthcheck(ctx context.Context) error {
 	return nil
 }
 
-// SendCommand sends a command to the executor server.
-func (c *Client) SendCommand(ctx context.Context, command string) error {
+// SendCommand sends a command with its arguments to the executor server and waits for the response.
+// It retries with backoff while the executor is unavailable, and returns a *CommandError if the command failed.
+func (c *Client) SendCommand(ctx context.Context, inv command.Invocation) (protocol.Response, error) {
+	req := protocol.NewRequest(inv, c.source)
+	delay := c.retryDelay
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.sendRequest(ctx, req)
+		if err == nil || !errors.Is(err, ErrUnavailable) || attempt >= c.retries {
+			return resp, err
+		}
+
+		if c.debug {
+			log.Println(fmt.Sprintf("retrying command %q in %s: %s", req.Command, delay, err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return protocol.Response{}, fmt.Errorf("%w: %w", ErrUnavailable, ctx.Err())
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+}
+
+// sendRequest sends the request to the executor server once and waits for the response.
+func (c *Client) sendRequest(ctx context.Context, req protocol.Request) (protocol.Response, error) {
+	data, err := protocol.Encode(req)
+	if err != nil {
+		return protocol.Response{}, err
+	}
+
 	// Connect to the executor.
 	dialer := &net.Dialer{}
 	conn, err := dialer.DialContext(ctx, "tcp", c.address)
 	if err != nil {
-		return fmt.Errorf("failed to connect to executor: %w", err)
+		return protocol.Response{}, fmt.Errorf("%w: failed to connect: %w", ErrUnavailable, err)
 	}
 	defer conn.Close()
 
+	// Stop waiting for the response when the context is done.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return protocol.Response{}, fmt.Errorf("failed to set deadline: %w", err)
+		}
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
 	// Send the command to the executor.
-	_, err = conn.Write([]byte(command))
+	if _, err := conn.Write(data); err != nil {
+		return protocol.Response{}, fmt.Errorf("%w: failed to send command: %w", ErrNoResponse, err)
+	}
+
+	// Wait for the response of the executor.
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
 	if err != nil {
-		return fmt.Errorf("failed to send command to executor: %w", err)
+		return protocol.Response{}, fmt.Errorf("%w: %w", ErrNoResponse, err)
 	}
 
-	return nil
+	resp, err := protocol.DecodeResponse(line)
+	if err != nil {
+		return protocol.Response{}, fmt.Errorf("%w: %w", ErrNoResponse, err)
+	}
+
+	if resp.ID != req.ID {
+		return resp, fmt.Errorf("%w: response id %q does not match request id %q", ErrNoResponse, resp.ID, req.ID)
+	}
+
+	if c.debug {
+		log.Println(fmt.Sprintf("executor response: %+v", resp))
+	}
+
+	if !resp.OK {
+		return resp, &CommandError{
+			ID:      resp.ID,
+			Command: req.Command,
+			Message: resp.Error,
+		}
+	}
+
+	return resp, nil
 }
@@ -1,15 +0,0 @@
-package executor
-
-var (
-	// Commands is the list of commands that the executor can execute.
-	Commands = []string{
-		"pause_video",
-		"play_video",
-	}
-
-	// Instructions is the list of instructions that the executor can execute.
-	Instructions = []string{
-		"play the video",
-		"pause the video",
-	}
-)
@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnavailable is returned when the command could not be sent to the executor.
+	// The command was not executed, so it is safe to retry.
+	ErrUnavailable = errors.New("executor unavailable")
+	// ErrNoResponse is returned when the command was sent but the response was not received.
+	// The command may have been executed, so it is not safe to retry.
+	ErrNoResponse = errors.New("executor did not respond")
+	// ErrCommandFailed is returned when the executor failed to execute the command.
+	ErrCommandFailed = errors.New("executor failed command")
+)
+
+// CommandError is the error of a command that the executor failed to execute.
+type CommandError struct {
+	// ID is the ID of the request.
+	ID string
+	// Command is the name of the command.
+	Command string
+	// Message is the error reported by the executor.
+	Message string
+}
+
+// Error returns the error message.
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %q (%s): %s", ErrCommandFailed, e.Command, e.ID, e.Message)
+}
+
+// Unwrap allows matching the error with ErrCommandFailed.
+func (e *CommandError) Unwrap() error {
+	return ErrCommandFailed
+}
@@ -213,10 +213,11 @@ func (c *Combiner) combineChunks(ctx context.Context, currChunkPath, prevChunkPa
 		input = currChunkPath
 	}
 
+	// Decode the aac chunks into pcm, copying the stream would keep aac inside the wav container.
 	args := buildFfmpegArgs(
 		combinedFfmpegArgs,
 		[]string{"-i", input},
-		[]string{"-c", "copy", combinedPath},
+		[]string{combinedPath},
 	)
 
 	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
@@ -0,0 +1,126 @@
+package ffmpeg
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// wavFormat is the format of a WAV file.
+type wavFormat struct {
+	AudioFormat   uint16
+	Channels      uint16
+	SampleRate    uint32
+	BitsPerSample uint16
+	DataSize      uint32
+}
+
+// readWAVFormat reads the format and the data size of the WAV file.
+func readWAVFormat(t *testing.T, path string) wavFormat {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		t.Fatalf("%s is not a WAV file", path)
+	}
+
+	var format wavFormat
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := data[offset+8 : min(offset+8+size, len(data))]
+
+		switch id {
+		case "fmt ":
+			format.AudioFormat = binary.LittleEndian.Uint16(body[0:2])
+			format.Channels = binary.LittleEndian.Uint16(body[2:4])
+			format.SampleRate = binary.LittleEndian.Uint32(body[4:8])
+			format.BitsPerSample = binary.LittleEndian.Uint16(body[14:16])
+		case "data":
+			format.DataSize = uint32(len(body))
+		}
+
+		offset += 8 + size + size%2
+	}
+
+	return format
+}
+
+// writeTestChunk writes a second of tone as an aac chunk, like the recorder.
+func writeTestChunk(t *testing.T, path string) {
+	t.Helper()
+
+	cmd := exec.Command("ffmpeg", "-loglevel", "error", "-y",
+		"-f", "lavfi", "-i", "sine=frequency=440:duration=1",
+		"-acodec", chunkFormat, "-ar", "16000", "-ac", "1", "-f", "adts", path,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to write chunk: %v: %s", err, output)
+	}
+}
+
+func TestCombineChunksIsPCM(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg is not installed")
+	}
+
+	tests := []struct {
+		name      string
+		chunks    []int
+		handle    int
+		minSecond float64
+	}{
+		{name: "first chunk", chunks: []int{0}, handle: 0, minSecond: 0.9},
+		{name: "with the previous chunk", chunks: []int{0, 1}, handle: 1, minSecond: 1.9},
+		{name: "wrapped previous chunk", chunks: []int{0, 5}, handle: 0, minSecond: 1.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			for _, i := range tt.chunks {
+				writeTestChunk(t, filepath.Join(inputDir, fmt.Sprintf(chunkPattern, i)))
+			}
+
+			var combined []string
+			c, err := NewCombiner(CombinerConfig{
+				ChunksNum: 6,
+				InputDir:  inputDir,
+				OutputDir: t.TempDir(),
+				OnCombined: func(_ context.Context, filePath string) error {
+					combined = append(combined, filePath)
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("NewCombiner() failed: %v", err)
+			}
+
+			if err := c.handleChunk(context.Background(), filepath.Join(inputDir, fmt.Sprintf(chunkPattern, tt.handle))); err != nil {
+				t.Fatalf("handleChunk() failed: %v", err)
+			}
+
+			if len(combined) != 1 {
+				t.Fatalf("combined %d files, want 1", len(combined))
+			}
+
+			// Whisper.cpp and the whisper worker only read 16 kHz mono 16-bit PCM.
+			format := readWAVFormat(t, combined[0])
+			if format.AudioFormat != 1 || format.Channels != 1 || format.SampleRate != 16000 || format.BitsPerSample != 16 {
+				t.Errorf("combined format = %+v, want 16 kHz mono 16-bit PCM", format)
+			}
+
+			if seconds := float64(format.DataSize) / (16000 * 2); seconds < tt.minSecond {
+				t.Errorf("combined %.2fs of audio, want at least %.2fs", seconds, tt.minSecond)
+			}
+		})
+	}
+}
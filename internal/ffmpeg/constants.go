@@ -40,7 +40,7 @@ var (
 // Combined constants.
 const (
 	// combinedFormat is the format of the combined ffmpeg file of X chunks.
-	// We use wav because Whisper requires wav files to transcribe the audio.
+	// We use 16-bit pcm wav because it is the only format every transcriber reads, eg. whisper.cpp without ffmpeg.
 	combinedFormat = "wav"
 )
 
@@ -49,6 +49,8 @@ var (
 	// combinedPattern is the pattern for the ffmpeg combined file of X chunks.
 	// We purposefully use `%%d` to escape the `%` character so the result is `combined_%d.wav`
 	combinedPattern = fmt.Sprintf("combined_%%d.%s", combinedFormat)
+	// combinedRegex is the regex for the ffmpeg combined file, capturing its unix nano timestamp.
+	combinedRegex = regexp.MustCompile(fmt.Sprintf(`combined_(\d+)\.%s$`, combinedFormat))
 	// combinedFfmpegArgs is the ffmpeg args for the combined file.
 	combinedFfmpegArgs = []string{
 		// Use 16-bit signed little-endian PCM audio (raw, uncompressed)
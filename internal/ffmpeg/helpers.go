@@ -3,6 +3,8 @@ package ffmpeg
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 // buildFfmpegArgs builds the arguments for the ffmpeg command.
@@ -25,3 +27,18 @@ func createDirIfNotExists(dir string) error {
 
 	return nil
 }
+
+// CombinedTime returns when the combined file was created, from its name.
+func CombinedTime(filePath string) (time.Time, error) {
+	parts := combinedRegex.FindStringSubmatch(filePath)
+	if len(parts) != 2 {
+		return time.Time{}, fmt.Errorf("invalid combined file name: %s", filePath)
+	}
+
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid combined file (%s) timestamp: %w", filePath, err)
+	}
+
+	return time.Unix(0, nanos), nil
+}
@@ -0,0 +1,276 @@
+// Package hallucination provides a filter for whisper hallucinations.
+package hallucination
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/nizarmah/jarvis/internal/whisper"
+)
+
+// Reason is the reason a transcript was dropped.
+type Reason string
+
+// Reasons.
+const (
+	// ReasonEmpty is an empty transcript.
+	ReasonEmpty Reason = "empty"
+	// ReasonBlocklist is a transcript that is a known hallucination.
+	ReasonBlocklist Reason = "blocklist"
+	// ReasonRepetition is a transcript with a phrase repeated over and over.
+	ReasonRepetition Reason = "repetition"
+	// ReasonSilence is a transcript that whisper considers silence.
+	ReasonSilence Reason = "silence"
+	// ReasonNoSpeech is a transcript with a high no-speech probability.
+	ReasonNoSpeech Reason = "no_speech"
+	// ReasonLowConfidence is a transcript with a low average log probability.
+	ReasonLowConfidence Reason = "low_confidence"
+	// ReasonCompressionRatio is a transcript with a high compression ratio.
+	ReasonCompressionRatio Reason = "compression_ratio"
+)
+
+// DefaultBlocklist is the list of phrases whisper commonly hallucinates on silence and noise.
+var DefaultBlocklist = []string{
+	"you",
+	"thank you",
+	"thanks",
+	"thanks for watching",
+	"thank you for watching",
+	"thank you so much for watching",
+	"please subscribe",
+	"subscribe to my channel",
+	"like and subscribe",
+	"see you next time",
+	"bye",
+	"so",
+	"okay",
+	"oh",
+	"um",
+	"music",
+	"blank audio",
+	"silence",
+	"you are a voice assistant",
+}
+
+// maxNgram is the longest phrase checked for repetitions.
+const maxNgram = 4
+
+// Config is the configuration for the filter.
+type Config struct {
+	// Blocklist is the list of extra phrases to drop, on top of the default blocklist.
+	Blocklist []string
+	// Debug enables logging the dropped transcripts.
+	Debug bool
+	// MaxRepeats is the maximum consecutive repeats of a phrase, eg. `pause pause pause`.
+	MaxRepeats int
+	// MaxNoSpeechProb is the maximum no-speech probability of any segment.
+	MaxNoSpeechProb float64
+	// MinAvgLogprob is the minimum average log probability of the transcript.
+	MinAvgLogprob float64
+	// MaxCompressionRatio is the maximum compression ratio of any segment.
+	MaxCompressionRatio float64
+}
+
+// Stats are the metrics of the filter.
+type Stats struct {
+	// Passed is the number of transcripts that passed the filter.
+	Passed int
+	// Dropped is the number of transcripts dropped, by reason.
+	Dropped map[Reason]int
+}
+
+// String formats the stats, eg. `passed: 3, dropped: blocklist=2 silence=5`.
+func (s Stats) String() string {
+	reasons := slices.Sorted(maps.Keys(s.Dropped))
+
+	dropped := make([]string, len(reasons))
+	for i, r := range reasons {
+		dropped[i] = fmt.Sprintf("%s=%d", r, s.Dropped[r])
+	}
+
+	return fmt.Sprintf("passed: %d, dropped: %s", s.Passed, strings.Join(dropped, " "))
+}
+
+// Filter drops transcripts that are likely whisper hallucinations.
+type Filter struct {
+	blocklist           map[string]bool
+	debug               bool
+	maxRepeats          int
+	maxNoSpeechProb     float64
+	minAvgLogprob       float64
+	maxCompressionRatio float64
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewFilter creates a new filter.
+func NewFilter(cfg Config) (*Filter, error) {
+	if cfg.MaxRepeats < 1 {
+		return nil, fmt.Errorf("max repeats must be at least 1")
+	}
+
+	if cfg.MaxNoSpeechProb <= 0 || cfg.MaxNoSpeechProb > 1 {
+		return nil, fmt.Errorf("max no speech probability must be in (0, 1]")
+	}
+
+	if cfg.MinAvgLogprob >= 0 {
+		return nil, fmt.Errorf("min average log probability must be negative")
+	}
+
+	if cfg.MaxCompressionRatio <= 0 {
+		return nil, fmt.Errorf("max compression ratio must be positive")
+	}
+
+	blocklist := make(map[string]bool, len(DefaultBlocklist)+len(cfg.Blocklist))
+	for _, phrase := range slices.Concat(DefaultBlocklist, cfg.Blocklist) {
+		if normalized := normalize(phrase); normalized != "" {
+			blocklist[normalized] = true
+		}
+	}
+
+	return &Filter{
+		blocklist:           blocklist,
+		debug:               cfg.Debug,
+		maxRepeats:          cfg.MaxRepeats,
+		maxNoSpeechProb:     cfg.MaxNoSpeechProb,
+		minAvgLogprob:       cfg.MinAvgLogprob,
+		maxCompressionRatio: cfg.MaxCompressionRatio,
+		stats: Stats{
+			Dropped: map[Reason]int{},
+		},
+	}, nil
+}
+
+// Check checks the transcript, returning the reason and false if it should be dropped.
+func (f *Filter) Check(t whisper.Transcript) (Reason, bool) {
+	reason, ok := f.check(t)
+	return f.record(t, reason, ok)
+}
+
+// CheckDictation checks a dictated transcript, only dropping the silence and the unconfident speech.
+// It skips the blocklist and the repetitions, dictating `thank you` or `bye bye bye` is legit.
+func (f *Filter) CheckDictation(t whisper.Transcript) (Reason, bool) {
+	reason, ok := f.checkDictation(t)
+	return f.record(t, reason, ok)
+}
+
+// record updates the stats with the result of a check.
+func (f *Filter) record(t whisper.Transcript, reason Reason, ok bool) (Reason, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ok {
+		f.stats.Passed++
+		return "", true
+	}
+
+	f.stats.Dropped[reason]++
+
+	if f.debug {
+		log.Println(fmt.Sprintf("dropped transcript %q: %s", t.Text, reason))
+	}
+
+	return reason, false
+}
+
+// Stats returns a snapshot of the metrics of the filter.
+func (f *Filter) Stats() Stats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return Stats{
+		Passed:  f.stats.Passed,
+		Dropped: maps.Clone(f.stats.Dropped),
+	}
+}
+
+// check runs the checks from the cheapest to the most expensive.
+func (f *Filter) check(t whisper.Transcript) (Reason, bool) {
+	text := normalize(t.Text)
+	if text == "" {
+		return ReasonEmpty, false
+	}
+
+	if f.blocklist[text] {
+		return ReasonBlocklist, false
+	}
+
+	if hasRepetition(strings.Fields(text), f.maxRepeats) {
+		return ReasonRepetition, false
+	}
+
+	return f.checkMetadata(t)
+}
+
+// checkDictation runs the checks that do not depend on the words.
+func (f *Filter) checkDictation(t whisper.Transcript) (Reason, bool) {
+	if normalize(t.Text) == "" {
+		return ReasonEmpty, false
+	}
+
+	return f.checkMetadata(t)
+}
+
+// checkMetadata checks the confidence whisper reports on the transcript.
+func (f *Filter) checkMetadata(t whisper.Transcript) (Reason, bool) {
+	// The metadata checks only apply to backends that report it.
+	if len(t.Segments) == 0 {
+		return "", true
+	}
+
+	if t.IsSilence() {
+		return ReasonSilence, false
+	}
+
+	if t.MaxNoSpeechProb() > f.maxNoSpeechProb {
+		return ReasonNoSpeech, false
+	}
+
+	if t.AvgLogprob() < f.minAvgLogprob {
+		return ReasonLowConfidence, false
+	}
+
+	if t.MaxCompressionRatio() > f.maxCompressionRatio {
+		return ReasonCompressionRatio, false
+	}
+
+	return "", true
+}
+
+// hasRepetition checks if a phrase of up to maxNgram words repeats more than maxRepeats times in a row.
+func hasRepetition(words []string, maxRepeats int) bool {
+	for n := 1; n <= maxNgram; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			repeats := 1
+			for j := i + n; j+n <= len(words) && slices.Equal(words[i:i+n], words[j:j+n]); j += n {
+				repeats++
+			}
+
+			if repeats > maxRepeats {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// normalize lowercases the text and strips the punctuation, eg. `[BLANK_AUDIO]` -> `blank audio`.
+func normalize(text string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '\'':
+			return unicode.ToLower(r)
+		default:
+			return ' '
+		}
+	}, text)
+
+	return strings.Join(strings.Fields(cleaned), " ")
+}
@@ -0,0 +1,216 @@
+package hallucination
+
+import (
+	"testing"
+
+	"github.com/nizarmah/jarvis/internal/whisper"
+)
+
+// newTestFilter creates a filter with the example.env thresholds.
+func newTestFilter(t *testing.T, blocklist ...string) *Filter {
+	t.Helper()
+
+	f, err := NewFilter(Config{
+		Blocklist:           blocklist,
+		MaxRepeats:          3,
+		MaxNoSpeechProb:     0.8,
+		MinAvgLogprob:       -1.0,
+		MaxCompressionRatio: 2.4,
+	})
+	if err != nil {
+		t.Fatalf("NewFilter() failed: %v", err)
+	}
+
+	return f
+}
+
+// segment returns a transcript of a single second-long segment.
+func segment(text string, avgLogprob, noSpeechProb, compressionRatio float64) whisper.Transcript {
+	return whisper.Transcript{
+		Text: text,
+		Segments: []whisper.Segment{{
+			End:              1,
+			Text:             text,
+			AvgLogprob:       avgLogprob,
+			NoSpeechProb:     noSpeechProb,
+			CompressionRatio: compressionRatio,
+		}},
+	}
+}
+
+func TestCheckText(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantReason Reason
+	}{
+		{name: "empty", text: "  ", wantReason: ReasonEmpty},
+		{name: "only punctuation", text: "...", wantReason: ReasonEmpty},
+		{name: "blocklist", text: "Thank you.", wantReason: ReasonBlocklist},
+		{name: "blocklist is case and punctuation insensitive", text: "THANKS FOR WATCHING!", wantReason: ReasonBlocklist},
+		{name: "blocklist tag", text: "[BLANK_AUDIO]", wantReason: ReasonBlocklist},
+		{name: "custom blocklist", text: "Subtitles by the community", wantReason: ReasonBlocklist},
+		{name: "prompt leak", text: "You are a voice assistant.", wantReason: ReasonBlocklist},
+		{name: "blocklist phrase inside a sentence", text: "Thank you, pause the video."},
+		{name: "command", text: "Pause the video."},
+		{name: "repeats at the limit", text: "pause pause pause"},
+		{name: "repeats over the limit", text: "pause pause pause pause", wantReason: ReasonRepetition},
+		{name: "repeated phrase", text: "go back go back go back go back", wantReason: ReasonRepetition},
+		{name: "repeated phrase of max ngram", text: "one two three four one two three four one two three four one two three four", wantReason: ReasonRepetition},
+		{name: "repeated phrase over max ngram", text: "one two three four five one two three four five one two three four five one two three four five"},
+		{name: "repeats not in a row", text: "next next next then next"},
+	}
+
+	f := newTestFilter(t, "subtitles by the community")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Backends without metadata only go through the text checks.
+			reason, ok := f.Check(whisper.Transcript{Text: tt.text})
+			if reason != tt.wantReason || ok != (tt.wantReason == "") {
+				t.Errorf("Check(%q) = %q, %t, want %q", tt.text, reason, ok, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckMetadata(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript whisper.Transcript
+		wantReason Reason
+	}{
+		{name: "confident speech", transcript: segment("pause the video", -0.3, 0.1, 1.2)},
+		{name: "silence", transcript: segment("pause the video", -1.1, 0.7, 1.2), wantReason: ReasonSilence},
+		{name: "no speech at the limit", transcript: segment("pause the video", -0.3, 0.8, 1.2)},
+		{name: "no speech over the limit", transcript: segment("pause the video", -0.3, 0.81, 1.2), wantReason: ReasonNoSpeech},
+		{name: "logprob at the limit", transcript: segment("pause the video", -1.0, 0.1, 1.2)},
+		{name: "logprob under the limit", transcript: segment("pause the video", -1.01, 0.1, 1.2), wantReason: ReasonLowConfidence},
+		{name: "compression ratio at the limit", transcript: segment("pause the video", -0.3, 0.1, 2.4)},
+		{name: "compression ratio over the limit", transcript: segment("pause the video", -0.3, 0.1, 2.5), wantReason: ReasonCompressionRatio},
+		{name: "text checks come first", transcript: segment("thank you", -1.1, 0.9, 3), wantReason: ReasonBlocklist},
+		{
+			name: "logprob is weighted by duration",
+			transcript: whisper.Transcript{
+				Text: "jarvis pause the video",
+				Segments: []whisper.Segment{
+					{Start: 0, End: 3, Text: "jarvis pause", AvgLogprob: -0.5},
+					{Start: 3, End: 4, Text: "the video", AvgLogprob: -2},
+				},
+			},
+		},
+		{
+			name: "no speech of any segment",
+			transcript: whisper.Transcript{
+				Text: "jarvis pause the video",
+				Segments: []whisper.Segment{
+					{Start: 0, End: 3, Text: "jarvis pause", AvgLogprob: -0.2, NoSpeechProb: 0.1},
+					{Start: 3, End: 4, Text: "the video", AvgLogprob: -0.2, NoSpeechProb: 0.9},
+				},
+			},
+			wantReason: ReasonNoSpeech,
+		},
+	}
+
+	f := newTestFilter(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := f.Check(tt.transcript)
+			if reason != tt.wantReason || ok != (tt.wantReason == "") {
+				t.Errorf("Check(%+v) = %q, %t, want %q", tt.transcript, reason, ok, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckWakeWordPhrases(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		wantReason Reason
+	}{
+		// The wake word detector decides on the wake word alone, the filter keeps it.
+		{name: "wake word alone", text: "Jarvis."},
+		{name: "wake word and blocklist phrase", text: "Jarvis, thank you."},
+		{name: "wake word and command", text: "Hey Jarvis, pause the video."},
+		{name: "blocklist phrase before the wake word", text: "Okay Jarvis, play."},
+		{name: "repeated wake word", text: "Jarvis Jarvis Jarvis Jarvis", wantReason: ReasonRepetition},
+		{name: "blocked wake word", text: "Hey Jarvis!", wantReason: ReasonBlocklist},
+	}
+
+	f := newTestFilter(t, "hey jarvis")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := f.Check(segment(tt.text, -0.3, 0.1, 1.2))
+			if reason != tt.wantReason || ok != (tt.wantReason == "") {
+				t.Errorf("Check(%q) = %q, %t, want %q", tt.text, reason, ok, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckDictation(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript whisper.Transcript
+		wantReason Reason
+	}{
+		// Dictation types the common phrases whisper hallucinates, they are likely said.
+		{name: "blocklist", transcript: segment("Thank you.", -0.3, 0.1, 1.2)},
+		{name: "blocklist without metadata", transcript: whisper.Transcript{Text: "Okay"}},
+		{name: "repetition", transcript: segment("bye bye bye bye", -0.3, 0.1, 1.2)},
+		{name: "empty", transcript: whisper.Transcript{Text: " "}, wantReason: ReasonEmpty},
+		{name: "silence", transcript: segment("thanks", -1.1, 0.7, 1.2), wantReason: ReasonSilence},
+		{name: "no speech over the limit", transcript: segment("thanks", -0.3, 0.81, 1.2), wantReason: ReasonNoSpeech},
+		{name: "logprob under the limit", transcript: segment("thanks", -1.01, 0.1, 1.2), wantReason: ReasonLowConfidence},
+		{name: "compression ratio over the limit", transcript: segment("thanks", -0.3, 0.1, 2.5), wantReason: ReasonCompressionRatio},
+	}
+
+	f := newTestFilter(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := f.CheckDictation(tt.transcript)
+			if reason != tt.wantReason || ok != (tt.wantReason == "") {
+				t.Errorf("CheckDictation(%+v) = %q, %t, want %q", tt.transcript, reason, ok, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	f := newTestFilter(t)
+
+	for _, text := range []string{"pause the video", "you", "bye", "", "play"} {
+		f.Check(whisper.Transcript{Text: text})
+	}
+
+	if got, want := f.Stats().String(), "passed: 2, dropped: blocklist=2 empty=1"; got != want {
+		t.Errorf("Stats() = %q, want %q", got, want)
+	}
+}
+
+func TestNewFilterErrors(t *testing.T) {
+	valid := Config{MaxRepeats: 3, MaxNoSpeechProb: 0.8, MinAvgLogprob: -1, MaxCompressionRatio: 2.4}
+
+	tests := []struct {
+		name   string
+		config func(cfg Config) Config
+	}{
+		{name: "no repeats", config: func(cfg Config) Config { cfg.MaxRepeats = 0; return cfg }},
+		{name: "no speech of zero", config: func(cfg Config) Config { cfg.MaxNoSpeechProb = 0; return cfg }},
+		{name: "no speech over one", config: func(cfg Config) Config { cfg.MaxNoSpeechProb = 1.1; return cfg }},
+		{name: "positive logprob", config: func(cfg Config) Config { cfg.MinAvgLogprob = 0; return cfg }},
+		{name: "no compression ratio", config: func(cfg Config) Config { cfg.MaxCompressionRatio = 0; return cfg }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFilter(tt.config(valid)); err == nil {
+				t.Errorf("NewFilter(%+v) succeeded, want an error", tt.config(valid))
+			}
+		})
+	}
+}
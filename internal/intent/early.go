@@ -0,0 +1,86 @@
+package intent
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// Early returns the intent of a partial reply, once the rest of the reply can't change it.
+// That is `do_nothing` as soon as it is written, the commands without parameters once their confidence is written,
+// since their arguments can only be empty, and the other commands once their arguments are written.
+func Early(reply string, registry *command.Registry) (Intent, bool) {
+	dec := json.NewDecoder(strings.NewReader(reply))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return Intent{}, false
+	}
+
+	var (
+		i             Intent
+		hasCommand    bool
+		hasConfidence bool
+		hasArgs       bool
+	)
+
+	// Only the top level keys count, eg. not the `command` argument of `schedule_command`.
+loop:
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			break
+		}
+
+		switch key {
+		case "command":
+			tok, err := dec.Token()
+			name, ok := tok.(string)
+			if err != nil || !ok {
+				break loop
+			}
+
+			i.Command = name
+			hasCommand = true
+
+		case "confidence":
+			tok, err := dec.Token()
+			confidence, ok := tok.(float64)
+			// A number is only complete once something follows it, eg. `0.9` may become `0.95`.
+			if err != nil || !ok || dec.InputOffset() >= int64(len(reply)) {
+				break loop
+			}
+
+			i.Confidence = confidence
+			hasConfidence = true
+
+		case "args":
+			if err := dec.Decode(&i.Args); err != nil {
+				break loop
+			}
+
+			hasArgs = true
+
+		default:
+			break loop
+		}
+	}
+
+	if hasCommand && i.Command == NoCommand {
+		return Intent{Command: NoCommand}, true
+	}
+
+	if !hasCommand || !hasConfidence {
+		return Intent{}, false
+	}
+
+	if !hasArgs {
+		cmd, ok := registry.Lookup(i.Command)
+		if !ok || len(cmd.Params) > 0 {
+			return Intent{}, false
+		}
+
+		i.Args = map[string]string{}
+	}
+
+	return i, true
+}
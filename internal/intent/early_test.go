@@ -0,0 +1,132 @@
+package intent
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// newTestRegistry creates the registry of the builtins and timers.
+func newTestRegistry(t *testing.T) *command.Registry {
+	t.Helper()
+
+	registry, err := command.NewDefaultRegistry()
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	for _, cmd := range command.TimerBuiltins {
+		if err := registry.Register(cmd); err != nil {
+			t.Fatalf("failed to register %q: %v", cmd.Name, err)
+		}
+	}
+
+	return registry
+}
+
+func TestEarly(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	tests := []struct {
+		name   string
+		reply  string
+		want   Intent
+		wantOK bool
+	}{
+		{name: "empty", reply: ``},
+		{name: "not an object", reply: `["pause_video"`},
+		{name: "truncated command name", reply: `{"command":"pause_vi`},
+		{name: "command without confidence", reply: `{"command":"pause_video",`},
+		{name: "truncated confidence", reply: `{"command":"pause_video","confidence":0.9`},
+		{
+			name:   "command without params, args pending",
+			reply:  `{"command":"pause_video","confidence":0.9,`,
+			want:   Intent{Command: "pause_video", Confidence: 0.9, Args: map[string]string{}},
+			wantOK: true,
+		},
+		{
+			name:   "command without params, args truncated",
+			reply:  `{"command":"pause_video","confidence":0.9,"args":{`,
+			want:   Intent{Command: "pause_video", Confidence: 0.9, Args: map[string]string{}},
+			wantOK: true,
+		},
+		{name: "command with params, args pending", reply: `{"command":"set_volume","confidence":0.9,`},
+		{name: "command with params, args truncated", reply: `{"command":"set_volume","confidence":0.9,"args":{"level":"4`},
+		{
+			name:   "command with params, args done",
+			reply:  `{"command":"set_volume","confidence":0.9,"args":{"level":"40"}`,
+			want:   Intent{Command: "set_volume", Confidence: 0.9, Args: map[string]string{"level": "40"}},
+			wantOK: true,
+		},
+		{
+			name:   "nested command argument",
+			reply:  `{"command":"schedule_command","confidence":0.8,"args":{"command":"pause_video","delay":"10m"}}`,
+			want:   Intent{Command: "schedule_command", Confidence: 0.8, Args: map[string]string{"command": "pause_video", "delay": "10m"}},
+			wantOK: true,
+		},
+		{name: "nested command argument truncated", reply: `{"command":"schedule_command","confidence":0.8,"args":{"command":"pause_video"`},
+		{
+			name:   "do nothing as soon as it is named",
+			reply:  `{"command":"do_nothing"`,
+			want:   Intent{Command: NoCommand},
+			wantOK: true,
+		},
+		{name: "unknown command", reply: `{"command":"self_destruct","confidence":0.9,`},
+		{
+			name:   "low confidence",
+			reply:  `{"command":"pause_video","confidence":0.2,`,
+			want:   Intent{Command: "pause_video", Confidence: 0.2, Args: map[string]string{}},
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Early(tt.reply, registry)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Early(%q) = %+v, %t, want %+v, %t", tt.reply, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEarlyLowConfidenceIsRejected(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	// The early intent is settled, but still checked like the whole reply.
+	in, ok := Early(`{"command":"pause_video","confidence":0.2,`, registry)
+	if !ok {
+		t.Fatalf("Early() did not settle the intent")
+	}
+
+	if _, err := in.Invocation(registry, 0.5); !errors.Is(err, ErrRejected) {
+		t.Errorf("Invocation() error = %v, want %v", err, ErrRejected)
+	}
+}
+
+func TestEarlyMatchesWholeReply(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	// Every prefix that settles early settles on the intent of the whole reply.
+	for _, ex := range Examples {
+		reply, err := json.Marshal(ex.Intent)
+		if err != nil {
+			t.Fatalf("failed to encode example %q: %v", ex.Transcript, err)
+		}
+
+		want, ok := Early(string(reply), registry)
+		if !ok {
+			t.Fatalf("Early(%s) did not settle the whole reply", reply)
+		}
+
+		for end := range len(reply) {
+			got, ok := Early(string(reply[:end]), registry)
+			if ok && !reflect.DeepEqual(got, want) {
+				t.Errorf("Early(%q) = %+v, want %+v of the whole reply", reply[:end], got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package intent provides the structured command the interpreter extracts from an instruction.
+package intent
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// NoCommand is the command of instructions that are not commands, eg. hallucinations.
+const NoCommand = "do_nothing"
+
+// ErrRejected is returned when the intent is not a valid command.
+var ErrRejected = errors.New("rejected intent")
+
+// Intent is the command the interpreter extracted from an instruction.
+type Intent struct {
+	// Command is the name of the command, or `do_nothing`.
+	Command string `json:"command"`
+	// Confidence is how sure the interpreter is, in [0, 1].
+	Confidence float64 `json:"confidence"`
+	// Args are the raw arguments of the command.
+	Args map[string]string `json:"args"`
+}
+
+// properties are the properties of the JSON schema of an intent, in the order the model writes them.
+// The command comes first, so it is known before the arguments are written.
+type properties struct {
+	Command    any `json:"command"`
+	Confidence any `json:"confidence"`
+	Args       any `json:"args"`
+}
+
+// Schema returns the JSON schema of the intents of the commands.
+// Each command only accepts its own arguments, and requires the required ones.
+func Schema(commands []command.Command) map[string]any {
+	variants := make([]any, 0, len(commands)+1)
+	for _, cmd := range commands {
+		properties := make(map[string]any, len(cmd.Params))
+		required := []string{}
+		for _, p := range cmd.Params {
+			properties[p.Name] = map[string]any{
+				"type":        "string",
+				"description": fmt.Sprintf("%s, a %s", p.Description, p.Type),
+			}
+
+			if p.Required {
+				required = append(required, p.Name)
+			}
+		}
+
+		variants = append(variants, variant(cmd.Name, map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}))
+	}
+
+	variants = append(variants, variant(NoCommand, map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{},
+		"additionalProperties": false,
+	}))
+
+	return map[string]any{
+		"anyOf": variants,
+	}
+}
+
+// variant returns the JSON schema of the intent of one command.
+func variant(name string, args map[string]any) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": properties{
+			Command:    map[string]any{"type": "string", "enum": []string{name}},
+			Confidence: map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+			Args:       args,
+		},
+		"required":             []string{"command", "confidence", "args"},
+		"additionalProperties": false,
+	}
+}
+
+// Invocation validates the intent against the registry, and returns its invocation.
+// The invocation is empty for `do_nothing`.
+func (i Intent) Invocation(registry *command.Registry, minConfidence float64) (command.Invocation, error) {
+	if i.Command == NoCommand {
+		return command.Invocation{}, nil
+	}
+
+	if i.Confidence < 0 || i.Confidence > 1 {
+		return command.Invocation{}, fmt.Errorf("%w: confidence %v is out of range [0, 1]", ErrRejected, i.Confidence)
+	}
+
+	if i.Confidence < minConfidence {
+		return command.Invocation{}, fmt.Errorf("%w: confidence %v is below %v", ErrRejected, i.Confidence, minConfidence)
+	}
+
+	// Models fill the optional arguments they were told to omit with empty values.
+	args := maps.Clone(i.Args)
+	maps.DeleteFunc(args, func(_, value string) bool {
+		return value == ""
+	})
+
+	inv := command.Invocation{
+		Name: i.Command,
+		Args: args,
+	}
+
+	if inv.Args == nil {
+		inv.Args = command.Args{}
+	}
+
+	if _, _, err := registry.Parse(inv); err != nil {
+		return command.Invocation{}, fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+
+	return inv, nil
+}
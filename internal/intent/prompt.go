@@ -0,0 +1,91 @@
+package intent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// PromptVersion identifies the system prompt and the examples, bump it when changing either.
+const PromptVersion = 2
+
+// systemPromptTemplate is the system prompt of the interpreter, formatted with the commands list.
+const systemPromptTemplate = "You are a command interpreter for audio transcripts generated by an AI model called Whisper. " +
+	"Whisper may hallucinate phrases, especially repetitive ones or filler like 'thank you for watching'. " +
+	"Your job is to determine if the transcript contains a valid instruction or if it's nonsense. " +
+	"If the input is valid, pick the closest matching command from this list: %s. " +
+	"Fill in the arguments of the command mentioned in the transcript, and omit the optional ones that are not. " +
+	"If it is a hallucination or unrelated content, pick 'do_nothing' with no arguments. " +
+	"Respond in JSON with the command, your confidence from 0 to 1, and its arguments."
+
+// Example is a few-shot example of an interpretation.
+type Example struct {
+	// Transcript is the instruction, without the wake word.
+	Transcript string
+	// Intent is the expected interpretation.
+	Intent Intent
+}
+
+// Examples are the curated few-shot examples, covering arguments, similar command names, and hallucinations.
+var Examples = []Example{
+	{
+		Transcript: "skip forward 30 seconds",
+		Intent:     Intent{Command: "seek_forward", Confidence: 0.95, Args: map[string]string{"seconds": "30s"}},
+	},
+	{
+		Transcript: "unmute the video",
+		Intent:     Intent{Command: "unmute_video", Confidence: 0.95, Args: map[string]string{}},
+	},
+	{
+		Transcript: "turn it down a bit",
+		Intent:     Intent{Command: "volume_down", Confidence: 0.8, Args: map[string]string{}},
+	},
+	{
+		Transcript: "pause spotify",
+		Intent:     Intent{Command: "pause_media", Confidence: 0.9, Args: map[string]string{"player": "spotify"}},
+	},
+	{
+		Transcript: "pause the video in 10 minutes",
+		Intent:     Intent{Command: "schedule_command", Confidence: 0.9, Args: map[string]string{"command": "pause_video", "delay": "10m"}},
+	},
+	{
+		Transcript: "set a pasta timer for 8 minutes",
+		Intent:     Intent{Command: "set_timer", Confidence: 0.95, Args: map[string]string{"duration": "8m", "name": "pasta"}},
+	},
+	{
+		Transcript: "thank you for watching",
+		Intent:     Intent{Command: NoCommand, Confidence: 0.9, Args: map[string]string{}},
+	},
+}
+
+// SystemPrompt returns the system prompt, listing the commands.
+func SystemPrompt(commands []command.Command) string {
+	lines := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		lines = append(lines, fmt.Sprintf(
+			"%s (%s, eg. %s)",
+			cmd.Usage(),
+			cmd.Description,
+			strings.Join(cmd.Examples, ", "),
+		))
+	}
+
+	return fmt.Sprintf(systemPromptTemplate, strings.Join(lines, " | "))
+}
+
+// UserPrompt returns the user message of the transcript.
+func UserPrompt(transcript string) string {
+	return fmt.Sprintf("Transcript: %q", transcript)
+}
+
+// ValidateExamples checks the examples are valid commands of the registry, so they never teach a wrong one.
+func ValidateExamples(registry *command.Registry, examples []Example) error {
+	for _, ex := range examples {
+		if _, err := ex.Intent.Invocation(registry, 0); err != nil {
+			return fmt.Errorf("invalid example %q: %w", ex.Transcript, err)
+		}
+	}
+
+	return nil
+}
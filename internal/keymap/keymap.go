@@ -0,0 +1,337 @@
+// Package keymap provides profiles that bind abstract actions to the keyboard shortcuts of media applications.
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupported is returned when the profile has no shortcut for the action.
+var ErrUnsupported = errors.New("unsupported by keymap profile")
+
+// Action is an abstract action that profiles bind to keys.
+type Action string
+
+// Actions.
+const (
+	ActionPlay       Action = "play"
+	ActionPause      Action = "pause"
+	ActionMute       Action = "mute"
+	ActionUnmute     Action = "unmute"
+	ActionNext       Action = "next"
+	ActionPrevious   Action = "previous"
+	ActionFullscreen Action = "fullscreen"
+	ActionTheater    Action = "theater"
+	ActionCaptions   Action = "captions"
+	ActionSkipAd     Action = "skip_ad"
+)
+
+// Key is a key tap with modifiers, eg. `k`, `shift+.`, `ctrl+right`.
+type Key string
+
+// Split returns the key and its modifiers, eg. `.` and `[shift]` for `shift+.`.
+func (k Key) Split() (string, []string) {
+	parts := strings.Split(string(k), "+")
+	return parts[len(parts)-1], parts[:len(parts)-1]
+}
+
+// Step is a key that changes a value by a fixed amount, eg. seeks 10 seconds.
+type Step struct {
+	Key    Key
+	Amount float64
+}
+
+// Profile binds the actions of an application to its shortcuts.
+type Profile struct {
+	// Name is the name of the profile, eg. `youtube`.
+	Name string
+	// Processes are the process names that select the profile, eg. `vlc`.
+	Processes []string
+	// Titles are the window title substrings that select the profile, eg. `youtube`.
+	Titles []string
+	// Keys bind the actions to keys.
+	Keys map[Action]Key
+	// SeekForward seeks forward by seconds, largest step first.
+	SeekForward []Step
+	// SeekBackward seeks backward by seconds, largest step first.
+	SeekBackward []Step
+	// JumpKeys jump to evenly spaced positions, eg. 10 keys jump to 0%, 10%, ..., 90%.
+	JumpKeys []Key
+	// VolumeUp raises the volume by a percentage.
+	VolumeUp Step
+	// VolumeDown lowers the volume by a percentage.
+	VolumeDown Step
+	// SpeedUp raises the playback speed.
+	SpeedUp Step
+	// SpeedDown lowers the playback speed.
+	SpeedDown Step
+	// MinSpeed is the lowest playback speed.
+	MinSpeed float64
+	// MaxSpeed is the highest playback speed.
+	MaxSpeed float64
+}
+
+// Key returns the key bound to the action.
+func (p Profile) Key(action Action) (Key, error) {
+	key, ok := p.Keys[action]
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no %s key", ErrUnsupported, p.Name, action)
+	}
+
+	return key, nil
+}
+
+// Seek returns the keys that seek by the seconds, backward when negative.
+// The seconds are rounded to the smallest step, tapped at least once.
+func (p Profile) Seek(seconds float64) ([]Key, error) {
+	steps := p.SeekForward
+	if seconds < 0 {
+		steps, seconds = p.SeekBackward, -seconds
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("%w: %s has no seek keys", ErrUnsupported, p.Name)
+	}
+
+	smallest := steps[len(steps)-1].Amount
+	remaining := max(math.Round(seconds/smallest), 1) * smallest
+
+	var keys []Key
+	for _, step := range steps {
+		// Tolerate float errors, eg. 3 * 0.1.
+		for remaining >= step.Amount-1e-9 {
+			keys = append(keys, step.Key)
+			remaining -= step.Amount
+		}
+	}
+
+	return keys, nil
+}
+
+// Jump returns the key that jumps to the position, in percent.
+// The position is rounded to the nearest jump key.
+func (p Profile) Jump(percent int) (Key, error) {
+	if len(p.JumpKeys) == 0 {
+		return "", fmt.Errorf("%w: %s has no jump keys", ErrUnsupported, p.Name)
+	}
+
+	spacing := 100 / float64(len(p.JumpKeys))
+	i := min(int(math.Round(float64(percent)/spacing)), len(p.JumpKeys)-1)
+
+	return p.JumpKeys[i], nil
+}
+
+// VolumeBy returns the keys that change the volume by the percentage, lowering it when negative.
+// It taps at least once.
+func (p Profile) VolumeBy(percent int) ([]Key, error) {
+	step := p.VolumeUp
+	if percent < 0 {
+		step, percent = p.VolumeDown, -percent
+	}
+
+	if step.Key == "" {
+		return nil, fmt.Errorf("%w: %s has no volume keys", ErrUnsupported, p.Name)
+	}
+
+	return repeat(step, float64(percent)), nil
+}
+
+// SpeedBy returns the keys that change the playback speed by the amount, lowering it when negative.
+// It taps at least once.
+func (p Profile) SpeedBy(amount float64) ([]Key, error) {
+	step := p.SpeedUp
+	if amount < 0 {
+		step, amount = p.SpeedDown, -amount
+	}
+
+	if step.Key == "" {
+		return nil, fmt.Errorf("%w: %s has no speed keys", ErrUnsupported, p.Name)
+	}
+
+	return repeat(step, amount), nil
+}
+
+// Volume returns the keys that set the volume to the level, in percent.
+// Applications have no absolute volume shortcut, so the volume is lowered to 0% first.
+func (p Profile) Volume(level int) ([]Key, error) {
+	if p.VolumeUp.Key == "" || p.VolumeDown.Key == "" {
+		return nil, fmt.Errorf("%w: %s has no volume keys", ErrUnsupported, p.Name)
+	}
+
+	downs := int(math.Ceil(100 / p.VolumeDown.Amount))
+	ups := int(math.Round(float64(level) / p.VolumeUp.Amount))
+
+	return append(slices.Repeat([]Key{p.VolumeDown.Key}, downs), slices.Repeat([]Key{p.VolumeUp.Key}, ups)...), nil
+}
+
+// Speed returns the keys that set the playback speed.
+// Applications have no absolute speed shortcut, so the speed is lowered to the minimum first.
+func (p Profile) Speed(speed float64) ([]Key, error) {
+	if p.SpeedUp.Key == "" || p.SpeedDown.Key == "" {
+		return nil, fmt.Errorf("%w: %s has no speed keys", ErrUnsupported, p.Name)
+	}
+
+	if speed < p.MinSpeed || speed > p.MaxSpeed {
+		return nil, fmt.Errorf("%w: %s speed %v is out of range [%v, %v]", ErrUnsupported, p.Name, speed, p.MinSpeed, p.MaxSpeed)
+	}
+
+	downs := int(math.Ceil((p.MaxSpeed - p.MinSpeed) / p.SpeedDown.Amount))
+	ups := int(math.Round((speed - p.MinSpeed) / p.SpeedUp.Amount))
+
+	return append(slices.Repeat([]Key{p.SpeedDown.Key}, downs), slices.Repeat([]Key{p.SpeedUp.Key}, ups)...), nil
+}
+
+// repeat returns the step key repeated to change a value by the amount, at least once.
+func repeat(step Step, amount float64) []Key {
+	return slices.Repeat([]Key{step.Key}, max(int(math.Round(amount/step.Amount)), 1))
+}
+
+// Config is the configuration for the keymap.
+type Config struct {
+	// Debug enables logging the selected profile.
+	Debug bool
+	// Profile pins the profile by name, empty selects it from the focused window.
+	Profile string
+	// Default is the profile used when none matches the focused window.
+	Default string
+	// Profiles are the available profiles, in matching order.
+	Profiles []Profile
+}
+
+// Keymap selects the profile of the focused application.
+type Keymap struct {
+	debug bool
+
+	pinned   *Profile
+	fallback Profile
+	profiles []Profile
+}
+
+// New creates a new keymap.
+func New(cfg Config) (*Keymap, error) {
+	k := &Keymap{
+		debug:    cfg.Debug,
+		profiles: cfg.Profiles,
+	}
+
+	fallback, ok := k.lookup(cfg.Default)
+	if !ok {
+		return nil, fmt.Errorf("unknown default keymap profile %q: expected one of %s", cfg.Default, strings.Join(k.Names(), ", "))
+	}
+
+	k.fallback = fallback
+
+	if cfg.Profile != "" {
+		pinned, ok := k.lookup(cfg.Profile)
+		if !ok {
+			return nil, fmt.Errorf("unknown keymap profile %q: expected one of %s", cfg.Profile, strings.Join(k.Names(), ", "))
+		}
+
+		k.pinned = &pinned
+	}
+
+	return k, nil
+}
+
+// Names returns the names of the profiles.
+func (k *Keymap) Names() []string {
+	names := make([]string, len(k.profiles))
+	for i, p := range k.profiles {
+		names[i] = p.Name
+	}
+
+	return names
+}
+
+// Select returns the profile of the focused window, matching the process first, then the title.
+// It returns the pinned profile when set, and the default one when nothing matches.
+func (k *Keymap) Select(title, process string) Profile {
+	if k.pinned != nil {
+		return *k.pinned
+	}
+
+	profile, ok := k.match(title, process)
+	if !ok {
+		profile = k.fallback
+	}
+
+	if k.debug {
+		log.Println(fmt.Sprintf("keymap profile %q for window %q (%s)", profile.Name, title, process))
+	}
+
+	return profile
+}
+
+// match returns the first profile matching the process, then the title.
+func (k *Keymap) match(title, process string) (Profile, bool) {
+	// Windows processes have an extension, eg. `vlc.exe`.
+	process = strings.TrimSuffix(strings.ToLower(process), ".exe")
+	title = strings.ToLower(title)
+
+	if process != "" {
+		for _, p := range k.profiles {
+			if slices.Contains(p.Processes, process) {
+				return p, true
+			}
+		}
+	}
+
+	if title != "" {
+		for _, p := range k.profiles {
+			if slices.ContainsFunc(p.Titles, func(t string) bool { return strings.Contains(title, t) }) {
+				return p, true
+			}
+		}
+	}
+
+	return Profile{}, false
+}
+
+// lookup returns the profile by name.
+func (k *Keymap) lookup(name string) (Profile, bool) {
+	i := slices.IndexFunc(k.profiles, func(p Profile) bool { return p.Name == name })
+	if i < 0 {
+		return Profile{}, false
+	}
+
+	return k.profiles[i], true
+}
+
+// Toggles remembers the state of the actions bound to a toggle key, eg. mute and unmute on YouTube,
+// so setting the same state twice taps the key once.
+type Toggles struct {
+	mu     sync.Mutex
+	states map[string]bool
+}
+
+// NewToggles creates a new toggles memory, where every state is unknown.
+func NewToggles() *Toggles {
+	return &Toggles{
+		states: map[string]bool{},
+	}
+}
+
+// Set remembers the state of the toggle, eg. `youtube mute`, and returns whether its key must be tapped.
+// An unknown state is assumed to be the opposite, eg. asking to unmute means it is muted.
+func (t *Toggles) Set(toggle string, on bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, known := t.states[toggle]
+	t.states[toggle] = on
+
+	return !known || state != on
+}
+
+// Forget forgets the state of the toggle, eg. when its key failed.
+func (t *Toggles) Forget(toggle string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.states, toggle)
+}
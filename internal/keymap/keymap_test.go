@@ -0,0 +1,153 @@
+package keymap
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// profile returns the builtin profile by name.
+func profile(t *testing.T, name string) Profile {
+	t.Helper()
+
+	i := slices.IndexFunc(Profiles, func(p Profile) bool { return p.Name == name })
+	if i < 0 {
+		t.Fatalf("unknown profile %q", name)
+	}
+
+	return Profiles[i]
+}
+
+func TestSeek(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		seconds float64
+		want    []Key
+	}{
+		{name: "largest steps first", profile: "youtube", seconds: 25, want: []Key{"l", "l", "right"}},
+		{name: "rounded to the smallest step", profile: "youtube", seconds: 12, want: []Key{"l"}},
+		{name: "less than the smallest step", profile: "youtube", seconds: 2, want: []Key{"right"}},
+		{name: "backward", profile: "youtube", seconds: -2, want: []Key{"left"}},
+		{name: "modifiers", profile: "vlc", seconds: -63, want: []Key{"ctrl+left", "shift+left"}},
+		{name: "single step", profile: "netflix", seconds: 30, want: []Key{"right", "right", "right"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := profile(t, tt.profile).Seek(tt.seconds)
+			if err != nil {
+				t.Fatalf("Seek(%v) failed: %v", tt.seconds, err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Seek(%v) = %q, want %q", tt.seconds, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeekUnsupported(t *testing.T) {
+	if _, err := profile(t, "media").Seek(10); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Seek() error = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestStepsTapAtLeastOnce(t *testing.T) {
+	youtube := profile(t, "youtube")
+
+	if got, _ := youtube.VolumeBy(1); !slices.Equal(got, []Key{"up"}) {
+		t.Errorf("VolumeBy(1) = %q, want one tap", got)
+	}
+
+	if got, _ := youtube.VolumeBy(-12); !slices.Equal(got, []Key{"down", "down"}) {
+		t.Errorf("VolumeBy(-12) = %q, want two taps", got)
+	}
+
+	if got, _ := youtube.SpeedBy(0.1); !slices.Equal(got, []Key{"shift+."}) {
+		t.Errorf("SpeedBy(0.1) = %q, want one tap", got)
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	youtube := profile(t, "youtube")
+
+	got, err := youtube.Speed(1.5)
+	if err != nil {
+		t.Fatalf("Speed(1.5) failed: %v", err)
+	}
+
+	want := append(slices.Repeat([]Key{"shift+,"}, 7), slices.Repeat([]Key{"shift+."}, 5)...)
+	if !slices.Equal(got, want) {
+		t.Errorf("Speed(1.5) = %q, want %q", got, want)
+	}
+
+	if _, err := youtube.Speed(3); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Speed(3) error = %v, want %v", err, ErrUnsupported)
+	}
+}
+
+func TestJump(t *testing.T) {
+	youtube := profile(t, "youtube")
+
+	for percent, want := range map[int]Key{0: "0", 34: "3", 70: "7", 96: "9", 100: "9"} {
+		if got, err := youtube.Jump(percent); err != nil || got != want {
+			t.Errorf("Jump(%d) = %q, %v, want %q", percent, got, err, want)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	keys, err := New(Config{Default: "media", Profiles: Profiles})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		title   string
+		process string
+		want    string
+	}{
+		{title: "Video - YouTube - Mozilla Firefox", process: "firefox", want: "youtube"},
+		{title: "Netflix", process: "chrome", want: "netflix"},
+		{title: "YouTube tutorial - VLC media player", process: "vlc.exe", want: "vlc"},
+		{title: "Terminal", process: "kitty", want: "media"},
+	}
+
+	for _, tt := range tests {
+		if got := keys.Select(tt.title, tt.process).Name; got != tt.want {
+			t.Errorf("Select(%q, %q) = %q, want %q", tt.title, tt.process, got, tt.want)
+		}
+	}
+}
+
+func TestToggles(t *testing.T) {
+	toggles := NewToggles()
+
+	steps := []struct {
+		on   bool
+		want bool
+	}{
+		// Unknown, asking to unmute means it is muted.
+		{on: false, want: true},
+		{on: false, want: false},
+		{on: true, want: true},
+		{on: true, want: false},
+		{on: false, want: true},
+	}
+
+	for i, step := range steps {
+		if got := toggles.Set("youtube mute", step.on); got != step.want {
+			t.Errorf("step %d: Set(%t) = %t, want %t", i, step.on, got, step.want)
+		}
+	}
+
+	toggles.Forget("youtube mute")
+	if !toggles.Set("youtube mute", false) {
+		t.Errorf("Set() after Forget() = false, want true")
+	}
+
+	if !toggles.Set("vlc mute", true) {
+		t.Errorf("Set() of another toggle = false, want true")
+	}
+}
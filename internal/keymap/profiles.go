@@ -0,0 +1,111 @@
+package keymap
+
+// Profiles is the list of builtin profiles, in matching order.
+var Profiles = []Profile{
+	{
+		Name:   "youtube",
+		Titles: []string{"youtube"},
+		Keys: map[Action]Key{
+			ActionPlay:       "k",
+			ActionPause:      "k",
+			ActionMute:       "m",
+			ActionUnmute:     "m",
+			ActionNext:       "shift+n",
+			ActionPrevious:   "shift+p",
+			ActionFullscreen: "f",
+			ActionTheater:    "t",
+			ActionCaptions:   "c",
+		},
+		SeekForward:  []Step{{Key: "l", Amount: 10}, {Key: "right", Amount: 5}},
+		SeekBackward: []Step{{Key: "j", Amount: 10}, {Key: "left", Amount: 5}},
+		JumpKeys:     []Key{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+		VolumeUp:     Step{Key: "up", Amount: 5},
+		VolumeDown:   Step{Key: "down", Amount: 5},
+		SpeedUp:      Step{Key: "shift+.", Amount: 0.25},
+		SpeedDown:    Step{Key: "shift+,", Amount: 0.25},
+		MinSpeed:     0.25,
+		MaxSpeed:     2,
+	},
+	{
+		Name:   "netflix",
+		Titles: []string{"netflix"},
+		Keys: map[Action]Key{
+			ActionPlay:       "space",
+			ActionPause:      "space",
+			ActionMute:       "m",
+			ActionUnmute:     "m",
+			ActionFullscreen: "f",
+		},
+		SeekForward:  []Step{{Key: "right", Amount: 10}},
+		SeekBackward: []Step{{Key: "left", Amount: 10}},
+		VolumeUp:     Step{Key: "up", Amount: 10},
+		VolumeDown:   Step{Key: "down", Amount: 10},
+	},
+	{
+		Name:      "vlc",
+		Processes: []string{"vlc"},
+		Titles:    []string{"vlc media player"},
+		Keys: map[Action]Key{
+			ActionPlay:       "space",
+			ActionPause:      "space",
+			ActionMute:       "m",
+			ActionUnmute:     "m",
+			ActionNext:       "n",
+			ActionPrevious:   "p",
+			ActionFullscreen: "f",
+			ActionCaptions:   "v",
+		},
+		SeekForward:  []Step{{Key: "ctrl+right", Amount: 60}, {Key: "alt+right", Amount: 10}, {Key: "shift+right", Amount: 3}},
+		SeekBackward: []Step{{Key: "ctrl+left", Amount: 60}, {Key: "alt+left", Amount: 10}, {Key: "shift+left", Amount: 3}},
+		VolumeUp:     Step{Key: "ctrl+up", Amount: 5},
+		VolumeDown:   Step{Key: "ctrl+down", Amount: 5},
+	},
+	{
+		Name:      "mpv",
+		Processes: []string{"mpv"},
+		Titles:    []string{" - mpv"},
+		Keys: map[Action]Key{
+			ActionPlay:       "space",
+			ActionPause:      "space",
+			ActionMute:       "m",
+			ActionUnmute:     "m",
+			ActionNext:       "shift+.",
+			ActionPrevious:   "shift+,",
+			ActionFullscreen: "f",
+			ActionCaptions:   "v",
+		},
+		SeekForward:  []Step{{Key: "up", Amount: 60}, {Key: "right", Amount: 5}},
+		SeekBackward: []Step{{Key: "down", Amount: 60}, {Key: "left", Amount: 5}},
+		VolumeUp:     Step{Key: "0", Amount: 2},
+		VolumeDown:   Step{Key: "9", Amount: 2},
+	},
+	{
+		Name:      "spotify",
+		Processes: []string{"spotify"},
+		Titles:    []string{"spotify"},
+		Keys: map[Action]Key{
+			ActionPlay:     "space",
+			ActionPause:    "space",
+			ActionNext:     "ctrl+right",
+			ActionPrevious: "ctrl+left",
+		},
+		SeekForward:  []Step{{Key: "shift+right", Amount: 5}},
+		SeekBackward: []Step{{Key: "shift+left", Amount: 5}},
+		VolumeUp:     Step{Key: "ctrl+up", Amount: 10},
+		VolumeDown:   Step{Key: "ctrl+down", Amount: 10},
+	},
+	{
+		// Media keys work in the background, so this profile is only used when pinned or default.
+		Name: "media",
+		Keys: map[Action]Key{
+			ActionPlay:     "audio_play",
+			ActionPause:    "audio_pause",
+			ActionMute:     "audio_mute",
+			ActionUnmute:   "audio_mute",
+			ActionNext:     "audio_next",
+			ActionPrevious: "audio_prev",
+		},
+		VolumeUp:   Step{Key: "audio_vol_up", Amount: 5},
+		VolumeDown: Step{Key: "audio_vol_down", Amount: 5},
+	},
+}
@@ -0,0 +1,229 @@
+// Package macro provides user-defined commands that run a sequence of commands, declared in the config file.
+package macro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// ErrInterrupted is returned when a new command interrupts the running macro.
+var ErrInterrupted = errors.New("interrupted")
+
+// Config is the declaration of a macro in the config file.
+type Config struct {
+	// Name is the name of the command, eg. `movie_mode`.
+	Name string `json:"name"`
+	// Description is a short description of the command.
+	Description string `json:"description"`
+	// Examples are example phrasings of the command.
+	Examples []string `json:"examples"`
+	// Steps are the commands run in order.
+	Steps []StepConfig `json:"steps"`
+}
+
+// StepConfig is the declaration of a macro step in the config file.
+type StepConfig struct {
+	// Command is the invocation of a registered command, eg. `set_volume level=60`.
+	Command string `json:"command"`
+	// Delay is the wait before the step, eg. `500ms`.
+	Delay string `json:"delay"`
+	// ContinueOnError runs the next steps when the step fails, instead of aborting the macro.
+	ContinueOnError bool `json:"continue_on_error"`
+}
+
+// step is a validated macro step.
+type step struct {
+	inv             command.Invocation
+	delay           time.Duration
+	continueOnError bool
+}
+
+// Runner runs the macros, one at a time.
+type Runner struct {
+	debug    bool
+	registry *command.Registry
+
+	mu      sync.Mutex
+	running uint64
+	cancel  context.CancelFunc
+	// wg tracks the macros running in the background.
+	wg sync.WaitGroup
+}
+
+// NewRunner creates a new runner, which runs the steps through the registry.
+func NewRunner(registry *command.Registry, debug bool) *Runner {
+	return &Runner{
+		debug:    debug,
+		registry: registry,
+	}
+}
+
+// Command creates the command of the macro.
+// The steps can only use commands registered before the macro, so macros never recurse.
+func (r *Runner) Command(cfg Config) (command.Command, error) {
+	if len(cfg.Steps) == 0 {
+		return command.Command{}, fmt.Errorf("macro %q: at least one step is required", cfg.Name)
+	}
+
+	steps := make([]step, len(cfg.Steps))
+	for i, s := range cfg.Steps {
+		inv, err := command.ParseInvocation(s.Command)
+		if err != nil {
+			return command.Command{}, fmt.Errorf("macro %q: step %d: %w", cfg.Name, i+1, err)
+		}
+
+		if _, _, err := r.registry.Parse(inv); err != nil {
+			return command.Command{}, fmt.Errorf("macro %q: step %d: %w", cfg.Name, i+1, err)
+		}
+
+		var delay time.Duration
+		if s.Delay != "" {
+			delay, err = time.ParseDuration(s.Delay)
+			if err != nil || delay < 0 {
+				return command.Command{}, fmt.Errorf("macro %q: step %d: invalid delay %q", cfg.Name, i+1, s.Delay)
+			}
+		}
+
+		steps[i] = step{
+			inv:             inv,
+			delay:           delay,
+			continueOnError: s.ContinueOnError,
+		}
+	}
+
+	return command.Command{
+		Name:        cfg.Name,
+		Description: cfg.Description,
+		Examples:    cfg.Examples,
+		Handler: func(ctx context.Context, _ command.Values) (string, error) {
+			r.start(ctx, cfg.Name, steps)
+			return fmt.Sprintf("started %s", cfg.Name), nil
+		},
+	}, nil
+}
+
+// Interrupt cancels the running macro, eg. when a new command arrives.
+func (r *Runner) Interrupt() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// Wait waits for the macros running in the background, eg. after interrupting them on exit.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// start interrupts the running macro, and runs the steps in the background, logging their results.
+// The macro does not block its caller, so the next command can interrupt it, eg. while it waits between steps.
+func (r *Runner) start(ctx context.Context, name string, steps []step) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	id := r.track(func() { cancel(ErrInterrupted) })
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.finish(id)
+		defer cancel(nil)
+
+		result, err := r.run(ctx, name, steps)
+		if err != nil {
+			log.Println(fmt.Sprintf("macro failed: %v", err))
+			return
+		}
+
+		if r.debug {
+			log.Println(fmt.Sprintf("macro %q done: %s", name, result))
+		}
+	}()
+}
+
+// run runs the steps in order, and returns their results.
+func (r *Runner) run(ctx context.Context, name string, steps []step) (string, error) {
+	results := make([]string, 0, len(steps))
+	for i, s := range steps {
+		if err := wait(ctx, s.delay); err != nil {
+			return "", fmt.Errorf("macro %q: step %d (%s): %w", name, i+1, s.inv, context.Cause(ctx))
+		}
+
+		if r.debug {
+			log.Println(fmt.Sprintf("macro %q: step %d: %s", name, i+1, s.inv))
+		}
+
+		result, err := r.registry.Dispatch(ctx, s.inv)
+		if err != nil && ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+
+		if err != nil && (!s.continueOnError || ctx.Err() != nil) {
+			return "", fmt.Errorf("macro %q: step %d (%s): %w", name, i+1, s.inv, err)
+		}
+
+		if err != nil {
+			if r.debug {
+				log.Println(fmt.Sprintf("macro %q: step %d failed, continuing: %v", name, i+1, err))
+			}
+
+			result = fmt.Sprintf("%s failed", s.inv.Name)
+		}
+
+		if result != "" {
+			results = append(results, result)
+		}
+	}
+
+	return strings.Join(results, "; "), nil
+}
+
+// track interrupts the running macro, and tracks the new one.
+func (r *Runner) track(cancel context.CancelFunc) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cancel != nil {
+		r.cancel()
+	}
+
+	r.running++
+	r.cancel = cancel
+
+	return r.running
+}
+
+// finish stops tracking the macro, unless a newer one replaced it.
+func (r *Runner) finish(id uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running == id {
+		r.cancel = nil
+	}
+}
+
+// wait waits for the delay, or until the context is done.
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
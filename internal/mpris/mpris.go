@@ -0,0 +1,479 @@
+// Package mpris provides a client for media players that implement the MPRIS D-Bus interface,
+// eg. Spotify, VLC, mpv, and browsers playing YouTube.
+// See https://specifications.freedesktop.org/mpris-spec/latest/.
+package mpris
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// D-Bus names of the MPRIS interface.
+const (
+	// busPrefix is the prefix of the bus names of the media players.
+	busPrefix = "org.mpris.MediaPlayer2."
+	// objectPath is the object path of the media players.
+	objectPath = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	// playerInterface is the interface that controls the playback.
+	playerInterface = "org.mpris.MediaPlayer2.Player"
+	// propertiesGet is the method that reads a property.
+	propertiesGet = "org.freedesktop.DBus.Properties.Get"
+	// propertiesSet is the method that writes a property.
+	propertiesSet = "org.freedesktop.DBus.Properties.Set"
+	// listNames is the method that lists the names on the bus.
+	listNames = "org.freedesktop.DBus.ListNames"
+)
+
+// Status is the playback status of a media player.
+type Status string
+
+// Statuses.
+const (
+	StatusPlaying Status = "Playing"
+	StatusPaused  Status = "Paused"
+	StatusStopped Status = "Stopped"
+)
+
+var (
+	// ErrNoPlayer is returned when no media player is running.
+	ErrNoPlayer = errors.New("no media player")
+	// ErrUnsupported is returned when the media player does not support the action.
+	ErrUnsupported = errors.New("unsupported by media player")
+)
+
+// processPlayers are the player names of the processes that play under another name.
+var processPlayers = map[string]string{
+	"chrome":        "chromium",
+	"google-chrome": "chromium",
+}
+
+// Player is a running media player.
+type Player struct {
+	// Name is the name of the player, eg. `spotify`.
+	Name string
+	// Status is the playback status of the player.
+	Status Status
+}
+
+// Config is the configuration for the client.
+type Config struct {
+	// Debug enables logging the actions.
+	Debug bool
+	// Player is the preferred media player, matched as a prefix of its name, eg. `spotify`.
+	// Empty picks the playing player, then the paused one.
+	Player string
+}
+
+// Client controls the media players on the session bus.
+type Client struct {
+	debug  bool
+	player string
+
+	conn *dbus.Conn
+}
+
+// NewClient creates a new client connected to the session bus.
+func NewClient(cfg Config) (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+
+	return &Client{
+		debug:  cfg.Debug,
+		player: cfg.Player,
+		conn:   conn,
+	}, nil
+}
+
+// Close closes the connection to the session bus.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Players lists the names of the running media players, eg. `spotify`, `vlc`.
+func (c *Client) Players(ctx context.Context) ([]string, error) {
+	var names []string
+	if err := c.conn.BusObject().CallWithContext(ctx, listNames, 0).Store(&names); err != nil {
+		return nil, fmt.Errorf("failed to list bus names: %w", err)
+	}
+
+	var players []string
+	for _, name := range names {
+		if player, ok := strings.CutPrefix(name, busPrefix); ok {
+			players = append(players, player)
+		}
+	}
+
+	slices.Sort(players)
+
+	return players, nil
+}
+
+// List lists the running media players with their playback status.
+func (c *Client) List(ctx context.Context) ([]Player, error) {
+	names, err := c.Players(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	players := make([]Player, 0, len(names))
+	for _, name := range names {
+		status, err := c.Status(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		players = append(players, Player{Name: name, Status: status})
+	}
+
+	return players, nil
+}
+
+// Active returns the media player to control.
+// It is the configured player when set, otherwise the playing player, then the paused one, then any.
+func (c *Client) Active(ctx context.Context) (string, error) {
+	if c.player != "" {
+		return c.find(ctx, c.player)
+	}
+
+	players, err := c.Players(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if len(players) == 0 {
+		return "", ErrNoPlayer
+	}
+
+	return c.best(ctx, players), nil
+}
+
+// Focused returns the media player of the focused application, eg. `firefox.instance_1_42` for `firefox`.
+// It is the configured player when set, otherwise the playing player of the process, then the paused one.
+// Unlike Active, it never picks the player of another application.
+func (c *Client) Focused(ctx context.Context, process string) (string, error) {
+	if c.player != "" {
+		return c.find(ctx, c.player)
+	}
+
+	// Windows processes have an extension, eg. `vlc.exe`, and browsers a variant, eg. `google-chrome`.
+	process = strings.TrimSuffix(strings.ToLower(process), ".exe")
+	if alias, ok := processPlayers[process]; ok {
+		process = alias
+	}
+
+	if process == "" {
+		return "", fmt.Errorf("%w: the focused window has no process", ErrNoPlayer)
+	}
+
+	players, err := c.Players(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// Browsers have instance suffixes, eg. `chromium.instance1234`.
+	players = slices.DeleteFunc(players, func(player string) bool {
+		name, _, _ := strings.Cut(strings.ToLower(player), ".")
+		return name == "" || !strings.Contains(process, name)
+	})
+
+	if len(players) == 0 {
+		return "", fmt.Errorf("%w: %q has no media player", ErrNoPlayer, process)
+	}
+
+	return c.best(ctx, players), nil
+}
+
+// best returns the playing player, then the paused one, then the first one.
+func (c *Client) best(ctx context.Context, players []string) string {
+	best, bestRank := players[0], 0
+	for _, player := range players {
+		status, err := c.Status(ctx, player)
+		if err != nil {
+			if c.debug {
+				log.Println(fmt.Sprintf("skipped media player %q: %s", player, err))
+			}
+
+			continue
+		}
+
+		if rank := statusRank(status); rank > bestRank {
+			best, bestRank = player, rank
+		}
+	}
+
+	return best
+}
+
+// Status returns the playback status of the media player.
+func (c *Client) Status(ctx context.Context, player string) (Status, error) {
+	var status string
+	if err := c.getProperty(ctx, player, "PlaybackStatus", &status); err != nil {
+		return "", err
+	}
+
+	return Status(status), nil
+}
+
+// Play plays the media player, unless it is already playing.
+// An empty player is the active one. It returns the player and whether it was not already playing.
+func (c *Client) Play(ctx context.Context, player string) (string, bool, error) {
+	return c.setStatus(ctx, player, StatusPlaying, "CanPlay", "Play")
+}
+
+// Pause pauses the media player, unless it is already paused or stopped.
+// An empty player is the active one. It returns the player and whether it was playing.
+func (c *Client) Pause(ctx context.Context, player string) (string, bool, error) {
+	return c.setStatus(ctx, player, StatusPaused, "CanPause", "Pause")
+}
+
+// Next skips to the next track of the media player.
+// An empty player is the active one. It returns the player.
+func (c *Client) Next(ctx context.Context, player string) (string, error) {
+	return c.do(ctx, player, "CanGoNext", "Next")
+}
+
+// Previous skips to the previous track of the media player.
+// An empty player is the active one. It returns the player.
+func (c *Client) Previous(ctx context.Context, player string) (string, error) {
+	return c.do(ctx, player, "CanGoPrevious", "Previous")
+}
+
+// Seek seeks the media player by the offset, backward when negative.
+// An empty player is the active one. It returns the player and its new position.
+func (c *Client) Seek(ctx context.Context, player string, offset time.Duration) (string, time.Duration, error) {
+	player, err := c.resolve(ctx, player)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := c.requireCapability(ctx, player, "CanSeek"); err != nil {
+		return player, 0, err
+	}
+
+	// MPRIS times are in microseconds.
+	if err := c.call(ctx, player, "Seek", offset.Microseconds()); err != nil {
+		return player, 0, err
+	}
+
+	var position int64
+	if err := c.getProperty(ctx, player, "Position", &position); err != nil {
+		return player, 0, err
+	}
+
+	if c.debug {
+		log.Println(fmt.Sprintf("media player %q: Seek %s", player, offset))
+	}
+
+	return player, time.Duration(position) * time.Microsecond, nil
+}
+
+// SetVolume sets the volume of the media player, from 0 to 1.
+// An empty player is the active one. It returns the player and the volume it reports.
+func (c *Client) SetVolume(ctx context.Context, player string, volume float64) (string, float64, error) {
+	player, err := c.resolve(ctx, player)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := c.requireCapability(ctx, player, "CanControl"); err != nil {
+		return player, 0, err
+	}
+
+	if err := c.setProperty(ctx, player, "Volume", volume); err != nil {
+		return player, 0, err
+	}
+
+	if err := c.getProperty(ctx, player, "Volume", &volume); err != nil {
+		return player, 0, err
+	}
+
+	if c.debug {
+		log.Println(fmt.Sprintf("media player %q: Volume %.2f", player, volume))
+	}
+
+	return player, volume, nil
+}
+
+// SetShuffle turns the shuffle of the media player on or off.
+// An empty player is the active one. It returns the player and the shuffle it reports.
+func (c *Client) SetShuffle(ctx context.Context, player string, shuffle bool) (string, bool, error) {
+	player, err := c.resolve(ctx, player)
+	if err != nil {
+		return "", false, err
+	}
+
+	if err := c.requireCapability(ctx, player, "CanControl"); err != nil {
+		return player, false, err
+	}
+
+	if err := c.setProperty(ctx, player, "Shuffle", shuffle); err != nil {
+		return player, false, err
+	}
+
+	if err := c.getProperty(ctx, player, "Shuffle", &shuffle); err != nil {
+		return player, false, err
+	}
+
+	if c.debug {
+		log.Println(fmt.Sprintf("media player %q: Shuffle %t", player, shuffle))
+	}
+
+	return player, shuffle, nil
+}
+
+// do calls the method on the media player, if it supports it.
+func (c *Client) do(ctx context.Context, player, capability, method string) (string, error) {
+	player, err := c.resolve(ctx, player)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.requireCapability(ctx, player, capability); err != nil {
+		return player, err
+	}
+
+	if err := c.call(ctx, player, method); err != nil {
+		return player, err
+	}
+
+	if c.debug {
+		log.Println(fmt.Sprintf("media player %q: %s", player, method))
+	}
+
+	return player, nil
+}
+
+// setStatus calls the method on the media player, unless it already has the status.
+// Unlike the toggle key, calling it twice is safe.
+func (c *Client) setStatus(ctx context.Context, player string, want Status, capability, method string) (string, bool, error) {
+	player, err := c.resolve(ctx, player)
+	if err != nil {
+		return "", false, err
+	}
+
+	status, err := c.Status(ctx, player)
+	if err != nil {
+		return player, false, err
+	}
+
+	// A stopped player is as good as paused.
+	if status == want || (want == StatusPaused && status == StatusStopped) {
+		if c.debug {
+			log.Println(fmt.Sprintf("media player %q already %s", player, strings.ToLower(string(status))))
+		}
+
+		return player, false, nil
+	}
+
+	// Players ignore the methods they do not support, so check before calling.
+	if err := c.requireCapability(ctx, player, capability); err != nil {
+		return player, false, err
+	}
+
+	if err := c.call(ctx, player, method); err != nil {
+		return player, false, err
+	}
+
+	if c.debug {
+		log.Println(fmt.Sprintf("media player %q: %s", player, method))
+	}
+
+	return player, true, nil
+}
+
+// resolve returns the running media player matching the name, or the active one when empty.
+func (c *Client) resolve(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return c.Active(ctx)
+	}
+
+	return c.find(ctx, name)
+}
+
+// find returns the running media player whose name starts with the prefix, ignoring case.
+// Browsers have instance suffixes, eg. `chromium.instance1234` matches `chromium`.
+func (c *Client) find(ctx context.Context, prefix string) (string, error) {
+	players, err := c.Players(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for _, player := range players {
+		if strings.HasPrefix(strings.ToLower(player), strings.ToLower(prefix)) {
+			return player, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q is not running", ErrNoPlayer, prefix)
+}
+
+// requireCapability checks that the media player supports an action, eg. `CanPause`.
+func (c *Client) requireCapability(ctx context.Context, player, capability string) error {
+	var supported bool
+	if err := c.getProperty(ctx, player, capability, &supported); err != nil {
+		return err
+	}
+
+	if !supported {
+		return fmt.Errorf("%w: %q is false for %q", ErrUnsupported, capability, player)
+	}
+
+	return nil
+}
+
+// call calls a method of the player interface.
+func (c *Client) call(ctx context.Context, player, method string, args ...any) error {
+	obj := c.conn.Object(busPrefix+player, objectPath)
+	if err := obj.CallWithContext(ctx, playerInterface+"."+method, 0, args...).Err; err != nil {
+		return fmt.Errorf("failed to call %s on %q: %w", method, player, err)
+	}
+
+	return nil
+}
+
+// getProperty reads a property of the player interface into v.
+func (c *Client) getProperty(ctx context.Context, player, name string, v any) error {
+	obj := c.conn.Object(busPrefix+player, objectPath)
+
+	var value dbus.Variant
+	if err := obj.CallWithContext(ctx, propertiesGet, 0, playerInterface, name).Store(&value); err != nil {
+		return fmt.Errorf("failed to get %s of %q: %w", name, player, err)
+	}
+
+	if err := value.Store(v); err != nil {
+		return fmt.Errorf("failed to read %s of %q: %w", name, player, err)
+	}
+
+	return nil
+}
+
+// setProperty writes a property of the player interface.
+func (c *Client) setProperty(ctx context.Context, player, name string, v any) error {
+	obj := c.conn.Object(busPrefix+player, objectPath)
+	if err := obj.CallWithContext(ctx, propertiesSet, 0, playerInterface, name, dbus.MakeVariant(v)).Err; err != nil {
+		return fmt.Errorf("failed to set %s of %q: %w", name, player, err)
+	}
+
+	return nil
+}
+
+// statusRank ranks the status by how likely the player is the one being listened to.
+func statusRank(status Status) int {
+	switch status {
+	case StatusPlaying:
+		return 2
+	case StatusPaused:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,366 @@
+package mpris
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// startBus starts a private session bus for the test, skipping it without `dbus-daemon`.
+func startBus(t *testing.T) {
+	t.Helper()
+
+	bin, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	cmd := exec.Command(bin, "--session", "--nofork", "--print-address")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("failed to open stdout: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dbus-daemon: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("failed to read bus address: %v", err)
+	}
+
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+}
+
+// newStub exports a stub player on the private bus, playing when asked.
+func newStub(t *testing.T, name string, playing bool) *stubPlayer {
+	t.Helper()
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatalf("failed to connect to bus: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	stub, err := newStubPlayer(conn, name, false)
+	if err != nil {
+		t.Fatalf("failed to export stub player %q: %v", name, err)
+	}
+
+	if playing {
+		stub.Play()
+	}
+
+	return stub
+}
+
+// newTestClient creates a client on the private bus, pinned to the player when set.
+func newTestClient(t *testing.T, player string) *Client {
+	t.Helper()
+
+	client, err := NewClient(Config{Player: player})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+func TestFocused(t *testing.T) {
+	startBus(t)
+
+	newStub(t, "spotify", true)
+	newStub(t, "firefox.instance_1_42", false)
+	newStub(t, "chromium.instance7", false)
+	newStub(t, "vlc", false)
+
+	tests := []struct {
+		name    string
+		pinned  string
+		process string
+		want    string
+	}{
+		{name: "browser instance", process: "firefox", want: "firefox.instance_1_42"},
+		{name: "browser variant", process: "firefox-esr", want: "firefox.instance_1_42"},
+		{name: "chrome plays as chromium", process: "chrome", want: "chromium.instance7"},
+		{name: "windows process", process: "VLC.exe", want: "vlc"},
+		{name: "pinned player", pinned: "spotify", process: "firefox", want: "spotify"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.pinned)
+
+			got, err := client.Focused(context.Background(), tt.process)
+			if err != nil {
+				t.Fatalf("Focused(%q) failed: %v", tt.process, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Focused(%q) = %q, want %q", tt.process, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFocusedNeverPicksAnotherApplication(t *testing.T) {
+	startBus(t)
+
+	spotify := newStub(t, "spotify", true)
+	client := newTestClient(t, "")
+
+	for _, process := range []string{"kitty", ""} {
+		if _, err := client.Focused(context.Background(), process); !errors.Is(err, ErrNoPlayer) {
+			t.Errorf("Focused(%q) error = %v, want %v", process, err, ErrNoPlayer)
+		}
+	}
+
+	// The active player is still spotify, for the commands that name no application.
+	if got, err := client.Active(context.Background()); err != nil || got != "spotify" {
+		t.Errorf("Active() = %q, %v, want %q", got, err, "spotify")
+	}
+
+	if calls := spotify.Calls(); len(calls) != 1 {
+		t.Errorf("spotify calls = %q, want only the setup call", calls)
+	}
+}
+
+func TestPauseFocusedLeavesOtherPlayers(t *testing.T) {
+	startBus(t)
+
+	spotify := newStub(t, "spotify", true)
+	firefox := newStub(t, "firefox.instance_1_42", true)
+	client := newTestClient(t, "")
+
+	player, err := client.Focused(context.Background(), "firefox")
+	if err != nil {
+		t.Fatalf("Focused() failed: %v", err)
+	}
+
+	if _, changed, err := client.Pause(context.Background(), player); err != nil || !changed {
+		t.Fatalf("Pause(%q) = %t, %v, want a change", player, changed, err)
+	}
+
+	if got := firefox.Status(); got != StatusPaused {
+		t.Errorf("firefox status = %s, want %s", got, StatusPaused)
+	}
+
+	if got := spotify.Status(); got != StatusPlaying {
+		t.Errorf("spotify status = %s, want %s", got, StatusPlaying)
+	}
+}
+
+func TestPlayPause(t *testing.T) {
+	startBus(t)
+
+	vlc := newStub(t, "vlc", false)
+	client := newTestClient(t, "")
+	ctx := context.Background()
+
+	steps := []struct {
+		name        string
+		do          func(ctx context.Context, player string) (string, bool, error)
+		wantChanged bool
+		wantStatus  Status
+	}{
+		{name: "play paused", do: client.Play, wantChanged: true, wantStatus: StatusPlaying},
+		{name: "play playing", do: client.Play, wantChanged: false, wantStatus: StatusPlaying},
+		{name: "pause playing", do: client.Pause, wantChanged: true, wantStatus: StatusPaused},
+		{name: "pause paused", do: client.Pause, wantChanged: false, wantStatus: StatusPaused},
+	}
+
+	for _, step := range steps {
+		player, changed, err := step.do(ctx, "vlc")
+		if err != nil {
+			t.Fatalf("%s: failed: %v", step.name, err)
+		}
+
+		if player != "vlc" || changed != step.wantChanged {
+			t.Errorf("%s: got %q, changed %t, want %q, changed %t", step.name, player, changed, "vlc", step.wantChanged)
+		}
+
+		if got := vlc.Status(); got != step.wantStatus {
+			t.Errorf("%s: status = %s, want %s", step.name, got, step.wantStatus)
+		}
+	}
+
+	// Only the changes call the player, unlike the toggle key.
+	if got, want := vlc.Calls(), []string{"Play", "Pause"}; !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestPauseStopped(t *testing.T) {
+	startBus(t)
+
+	vlc := newStub(t, "vlc", false)
+	vlc.Stop()
+	client := newTestClient(t, "")
+
+	if _, changed, err := client.Pause(context.Background(), "vlc"); err != nil || changed {
+		t.Errorf("Pause() = %t, %v, want no change", changed, err)
+	}
+}
+
+func TestNextPrevious(t *testing.T) {
+	startBus(t)
+
+	spotify := newStub(t, "spotify", true)
+	client := newTestClient(t, "")
+	ctx := context.Background()
+
+	if player, err := client.Next(ctx, ""); err != nil || player != "spotify" {
+		t.Fatalf("Next() = %q, %v, want %q", player, err, "spotify")
+	}
+
+	if player, err := client.Previous(ctx, "spotify"); err != nil || player != "spotify" {
+		t.Fatalf("Previous() = %q, %v, want %q", player, err, "spotify")
+	}
+
+	if got, want := spotify.Calls(), []string{"Play", "Next", "Previous"}; !slices.Equal(got, want) {
+		t.Errorf("calls = %q, want %q", got, want)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	startBus(t)
+
+	newStub(t, "mpv", true)
+	client := newTestClient(t, "")
+
+	tests := []struct {
+		offset time.Duration
+		want   time.Duration
+	}{
+		{offset: 30 * time.Second, want: 30 * time.Second},
+		{offset: -10 * time.Second, want: 20 * time.Second},
+		// Players stop at the start.
+		{offset: -time.Minute, want: 0},
+	}
+
+	for _, tt := range tests {
+		_, position, err := client.Seek(context.Background(), "mpv", tt.offset)
+		if err != nil {
+			t.Fatalf("Seek(%s) failed: %v", tt.offset, err)
+		}
+
+		if position != tt.want {
+			t.Errorf("Seek(%s) position = %s, want %s", tt.offset, position, tt.want)
+		}
+	}
+}
+
+func TestSetVolume(t *testing.T) {
+	startBus(t)
+
+	newStub(t, "spotify", true)
+	client := newTestClient(t, "")
+
+	player, volume, err := client.SetVolume(context.Background(), "", 0.4)
+	if err != nil {
+		t.Fatalf("SetVolume() failed: %v", err)
+	}
+
+	if player != "spotify" || volume != 0.4 {
+		t.Errorf("SetVolume() = %q, %v, want %q, %v", player, volume, "spotify", 0.4)
+	}
+}
+
+func TestSetShuffle(t *testing.T) {
+	startBus(t)
+
+	newStub(t, "spotify", true)
+	client := newTestClient(t, "")
+
+	for _, want := range []bool{true, false} {
+		_, shuffle, err := client.SetShuffle(context.Background(), "spotify", want)
+		if err != nil {
+			t.Fatalf("SetShuffle(%t) failed: %v", want, err)
+		}
+
+		if shuffle != want {
+			t.Errorf("SetShuffle(%t) = %t", want, shuffle)
+		}
+	}
+}
+
+func TestPlayerMatching(t *testing.T) {
+	startBus(t)
+
+	newStub(t, "spotify", false)
+	newStub(t, "chromium.instance1234", true)
+	newStub(t, "vlc", false)
+
+	tests := []struct {
+		name    string
+		pinned  string
+		player  string
+		want    string
+		wantErr error
+	}{
+		{name: "exact name", player: "vlc", want: "vlc"},
+		{name: "instance suffix", player: "chromium", want: "chromium.instance1234"},
+		{name: "case insensitive prefix", player: "Spot", want: "spotify"},
+		{name: "not running", player: "mpv", wantErr: ErrNoPlayer},
+		{name: "empty picks the playing one", player: "", want: "chromium.instance1234"},
+		{name: "empty picks the pinned one", pinned: "vlc", player: "", want: "vlc"},
+		{name: "named beats the pinned one", pinned: "vlc", player: "spotify", want: "spotify"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.pinned)
+
+			got, err := client.Next(context.Background(), tt.player)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Next(%q) error = %v, want %v", tt.player, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Next(%q) = %q, want %q", tt.player, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	startBus(t)
+
+	newStub(t, "vlc", false)
+	newStub(t, "spotify", true)
+	client := newTestClient(t, "")
+
+	players, err := client.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() failed: %v", err)
+	}
+
+	want := []Player{{Name: "spotify", Status: StatusPlaying}, {Name: "vlc", Status: StatusPaused}}
+	if !slices.Equal(players, want) {
+		t.Errorf("List() = %+v, want %+v", players, want)
+	}
+}
+
+func TestNoPlayer(t *testing.T) {
+	startBus(t)
+
+	client := newTestClient(t, "")
+
+	if _, _, err := client.Play(context.Background(), ""); !errors.Is(err, ErrNoPlayer) {
+		t.Errorf("Play() error = %v, want %v", err, ErrNoPlayer)
+	}
+}
@@ -0,0 +1,170 @@
+package mpris
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// rootInterface is the interface that describes the media player, only the stub implements it.
+const rootInterface = "org.mpris.MediaPlayer2"
+
+// stubPlayer is an in-memory media player exported on a private session bus, to test the client.
+type stubPlayer struct {
+	debug bool
+	name  string
+
+	props *prop.Properties
+
+	mu    sync.Mutex
+	calls []string
+}
+
+// newStubPlayer exports a paused stub player named `org.mpris.MediaPlayer2.<name>` on the bus.
+func newStubPlayer(conn *dbus.Conn, name string, debug bool) (*stubPlayer, error) {
+	s := &stubPlayer{
+		debug: debug,
+		name:  name,
+	}
+
+	props, err := prop.Export(conn, objectPath, prop.Map{
+		rootInterface: {
+			"CanQuit":             {Value: false},
+			"CanRaise":            {Value: false},
+			"HasTrackList":        {Value: false},
+			"Identity":            {Value: name},
+			"SupportedMimeTypes":  {Value: []string{}},
+			"SupportedUriSchemes": {Value: []string{}},
+		},
+		playerInterface: {
+			"CanControl":     {Value: true},
+			"CanGoNext":      {Value: true},
+			"CanGoPrevious":  {Value: true},
+			"CanPause":       {Value: true},
+			"CanPlay":        {Value: true},
+			"CanSeek":        {Value: true},
+			"LoopStatus":     {Value: "None", Writable: true, Emit: prop.EmitTrue},
+			"MaximumRate":    {Value: 2.0},
+			"Metadata":       {Value: map[string]dbus.Variant{}, Emit: prop.EmitTrue},
+			"MinimumRate":    {Value: 0.25},
+			"PlaybackStatus": {Value: string(StatusPaused), Emit: prop.EmitTrue},
+			"Position":       {Value: int64(0), Emit: prop.EmitFalse},
+			"Rate":           {Value: 1.0, Writable: true, Emit: prop.EmitTrue},
+			"Shuffle":        {Value: false, Writable: true, Emit: prop.EmitTrue},
+			"Volume":         {Value: 1.0, Writable: true, Emit: prop.EmitTrue},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export properties: %w", err)
+	}
+
+	s.props = props
+
+	if err := conn.ExportWithMap(s, map[string]string{"SeekBy": "Seek"}, objectPath, playerInterface); err != nil {
+		return nil, fmt.Errorf("failed to export player: %w", err)
+	}
+
+	reply, err := conn.RequestName(busPrefix+name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request name: %w", err)
+	}
+
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return nil, fmt.Errorf("name %q is already taken", busPrefix+name)
+	}
+
+	return s, nil
+}
+
+// Play implements the MPRIS Play method.
+func (s *stubPlayer) Play() *dbus.Error {
+	s.record("Play")
+	s.props.SetMust(playerInterface, "PlaybackStatus", string(StatusPlaying))
+	return nil
+}
+
+// Pause implements the MPRIS Pause method.
+func (s *stubPlayer) Pause() *dbus.Error {
+	s.record("Pause")
+	s.props.SetMust(playerInterface, "PlaybackStatus", string(StatusPaused))
+	return nil
+}
+
+// PlayPause implements the MPRIS PlayPause method.
+func (s *stubPlayer) PlayPause() *dbus.Error {
+	s.record("PlayPause")
+	if s.Status() == StatusPlaying {
+		s.props.SetMust(playerInterface, "PlaybackStatus", string(StatusPaused))
+	} else {
+		s.props.SetMust(playerInterface, "PlaybackStatus", string(StatusPlaying))
+	}
+	return nil
+}
+
+// Stop implements the MPRIS Stop method.
+func (s *stubPlayer) Stop() *dbus.Error {
+	s.record("Stop")
+	s.props.SetMust(playerInterface, "PlaybackStatus", string(StatusStopped))
+	return nil
+}
+
+// Next implements the MPRIS Next method.
+func (s *stubPlayer) Next() *dbus.Error {
+	s.record("Next")
+	s.props.SetMust(playerInterface, "Position", int64(0))
+	return nil
+}
+
+// Previous implements the MPRIS Previous method.
+func (s *stubPlayer) Previous() *dbus.Error {
+	s.record("Previous")
+	s.props.SetMust(playerInterface, "Position", int64(0))
+	return nil
+}
+
+// SeekBy implements the MPRIS Seek method, the offset is in microseconds.
+// It is renamed on export, since `Seek` is reserved for io.Seeker.
+func (s *stubPlayer) SeekBy(offset int64) *dbus.Error {
+	s.record(fmt.Sprintf("Seek %d", offset))
+	position := s.props.GetMust(playerInterface, "Position").(int64)
+	s.props.SetMust(playerInterface, "Position", max(position+offset, 0))
+	return nil
+}
+
+// SetPosition implements the MPRIS SetPosition method, the position is in microseconds.
+func (s *stubPlayer) SetPosition(_ dbus.ObjectPath, position int64) *dbus.Error {
+	s.record(fmt.Sprintf("SetPosition %d", position))
+	s.props.SetMust(playerInterface, "Position", max(position, 0))
+	return nil
+}
+
+// Status returns the playback status of the stub player.
+func (s *stubPlayer) Status() Status {
+	return Status(s.props.GetMust(playerInterface, "PlaybackStatus").(string))
+}
+
+// Calls returns the MPRIS methods called on the stub player, in order.
+func (s *stubPlayer) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	calls := make([]string, len(s.calls))
+	copy(calls, s.calls)
+
+	return calls
+}
+
+// record appends the method call.
+func (s *stubPlayer) record(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, method)
+
+	if s.debug {
+		log.Println(fmt.Sprintf("stub player %q: %s", s.name, method))
+	}
+}
@@ -0,0 +1,49 @@
+package ollama
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnavailable is returned when the request could not be sent to the Ollama server, eg. it is not running.
+	ErrUnavailable = errors.New("ollama unavailable")
+	// ErrTimeout is returned when the Ollama server did not respond within the request timeout.
+	ErrTimeout = errors.New("ollama timed out")
+	// ErrRequestFailed is returned when the Ollama server responded with an error.
+	ErrRequestFailed = errors.New("ollama request failed")
+	// ErrModelNotFound is returned when the model was not pulled on the Ollama server.
+	ErrModelNotFound = errors.New("model not found")
+	// ErrInvalidResponse is returned when the response does not match the requested format.
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// APIError is the error the Ollama server responded with.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Message is the `error` field of the response, or its body.
+	Message string
+}
+
+// Error returns the error message.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %d %s: %s", ErrRequestFailed, e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Unwrap allows matching the error with ErrModelNotFound for missing models, or ErrRequestFailed otherwise.
+// Other 404s, eg. `404 page not found` of a wrong URL, are not missing models.
+func (e *APIError) Unwrap() error {
+	if e.StatusCode == http.StatusNotFound && strings.HasPrefix(e.Message, "model ") && strings.Contains(e.Message, "not found") {
+		return ErrModelNotFound
+	}
+
+	return ErrRequestFailed
+}
+
+// Temporary reports whether the request may succeed when retried, eg. when the server is overloaded.
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
@@ -2,37 +2,72 @@
 package ollama
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // ClientConfig is the configuration for the Ollama client.
 type ClientConfig struct {
 	// Debug enables logging while prompting the LLM.
 	Debug bool
+	// KeepAlive is how long the model stays loaded after a request, negative keeps it loaded, zero is the server default.
+	KeepAlive time.Duration
 	// Model is the name of the model to use.
 	Model string
+	// Options are the generation options of the model.
+	Options Options
+	// Retries is the number of retries of the requests that failed temporarily, eg. the server is restarting.
+	Retries int
+	// RetryDelay is the delay before the first retry, doubled on each retry.
+	RetryDelay time.Duration
+	// Timeout is the timeout of each request attempt, zero only stops with the context.
+	// It does not apply to warming up, which loads the model.
+	Timeout time.Duration
 	// URL is the URL of the Ollama server.
 	URL string
 }
 
+// Options are the generation options of the model.
+type Options struct {
+	// Temperature is the randomness of the responses, 0 is the most predictable.
+	Temperature float64 `json:"temperature"`
+	// NumPredict is the maximum number of tokens of the responses, zero is unlimited.
+	NumPredict int `json:"num_predict,omitempty"`
+	// Stop are the sequences that end the responses.
+	Stop []string `json:"stop,omitempty"`
+	// Seed makes the responses reproducible for the same prompt, zero is random.
+	Seed int `json:"seed,omitempty"`
+}
+
 // Client is a client for the Ollama API.
 type Client struct {
-	debug bool
-	model string
-	url   string
+	debug      bool
+	keepAlive  string
+	model      string
+	options    Options
+	retries    int
+	retryDelay time.Duration
+	timeout    time.Duration
+	url        string
+
+	// http is shared by the requests, to reuse the connections.
+	http *http.Client
 }
 
-// GenerateInput is the input for the generate endpoint.
+// GenerateInput is the input for the generate endpoint, only used to load the model.
 type generateInput struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model     string `json:"model"`
+	Stream    bool   `json:"stream"`
+	KeepAlive string `json:"keep_alive,omitempty"`
 }
 
 // GenerateResult is the result for the generate endpoint.
@@ -40,59 +75,371 @@ type generateResult struct {
 	Response string `json:"response"`
 }
 
-// NewClient creates a new Ollama client with default config.
-func NewClient(cfg ClientConfig) *Client {
-	return &Client{
-		debug: cfg.Debug,
-		model: cfg.Model,
-		url:   cfg.URL,
+// Message roles.
+const (
+	// RoleSystem is the role of the instructions, before the conversation.
+	RoleSystem = "system"
+	// RoleUser is the role of the user messages.
+	RoleUser = "user"
+	// RoleAssistant is the role of the LLM replies, eg. in few-shot examples.
+	RoleAssistant = "assistant"
+)
+
+// Message is a message of a chat.
+type Message struct {
+	// Role is the author of the message, eg. `system`, `user`, or `assistant`.
+	Role string `json:"role"`
+	// Content is the text of the message.
+	Content string `json:"content"`
+}
+
+// ChatInput is the input for the chat endpoint.
+type chatInput struct {
+	Model    string    `json:"model"`
+	Messages []Message `json:"messages"`
+	Stream   bool      `json:"stream"`
+	// Format constrains the reply, eg. to `json` or to a JSON schema.
+	Format    any      `json:"format,omitempty"`
+	Options   *Options `json:"options,omitempty"`
+	KeepAlive string   `json:"keep_alive,omitempty"`
+}
+
+// ChatChunk is a chunk of the streamed result for the chat endpoint.
+type chatChunk struct {
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+	// Error is set when the generation fails after the response started.
+	Error string `json:"error,omitempty"`
+}
+
+// OnTokenFunc is called with each token of a streamed reply, and the reply so far.
+// It returns true to stop the reply early.
+type OnTokenFunc func(token string, reply string) (stop bool)
+
+// TagsResult is the result for the tags endpoint.
+type tagsResult struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// ErrorResult is the result of the failed requests.
+type errorResult struct {
+	Error string `json:"error"`
+}
+
+// maxErrorBody is the number of bytes of an error body kept in the error message.
+const maxErrorBody = 512
+
+// NewClient creates a new Ollama client.
+func NewClient(cfg ClientConfig) (*Client, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("model is required")
+	}
+
+	if cfg.Retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative")
+	}
+
+	if cfg.RetryDelay < 0 {
+		return nil, fmt.Errorf("retry delay must not be negative")
+	}
+
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
+	}
+
+	var keepAlive string
+	if cfg.KeepAlive != 0 {
+		keepAlive = cfg.KeepAlive.String()
 	}
+
+	return &Client{
+		debug:      cfg.Debug,
+		keepAlive:  keepAlive,
+		model:      cfg.Model,
+		options:    cfg.Options,
+		retries:    cfg.Retries,
+		retryDelay: cfg.RetryDelay,
+		timeout:    cfg.Timeout,
+		url:        strings.TrimRight(cfg.URL, "/"),
+		http:       &http.Client{},
+	}, nil
 }
 
-// Prompt sends a prompt to the LLM and returns the response.
-func (c *Client) Prompt(ctx context.Context, prompt string) (string, error) {
-	req, err := c.buildGenerateRequest(ctx, generateInput{
-		Model:  c.model,
-		Prompt: prompt,
-		Stream: false,
-	})
+// CheckModel checks the model was pulled on the Ollama server.
+func (c *Client) CheckModel(ctx context.Context) error {
+	var parsed tagsResult
+	if err := c.do(ctx, http.MethodGet, "/api/tags", nil, &parsed, c.timeout); err != nil {
+		return err
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("ollama request failed: %w", err)
+	// Models are listed with their tag, eg. `llama3:latest`.
+	names := make([]string, 0, len(parsed.Models))
+	for _, m := range parsed.Models {
+		if m.Name == c.model || m.Name == c.model+":latest" {
+			return nil
+		}
+
+		names = append(names, m.Name)
 	}
-	defer resp.Body.Close()
 
+	return fmt.Errorf("%w: %q, pull it with `ollama pull %s`, available: %q", ErrModelNotFound, c.model, c.model, names)
+}
+
+// WarmUp loads the model in memory, so the first prompt isn't slow.
+func (c *Client) WarmUp(ctx context.Context) error {
+	start := time.Now()
+
+	// An empty prompt only loads the model.
 	var parsed generateResult
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	if err := c.do(ctx, http.MethodPost, "/api/generate", generateInput{
+		Model:     c.model,
+		Stream:    false,
+		KeepAlive: c.keepAlive,
+	}, &parsed, 0); err != nil {
+		return fmt.Errorf("failed to warm up model: %w", err)
 	}
 
 	if c.debug {
+		log.Println(fmt.Sprintf("warmed up model %q in %s", c.model, time.Since(start).Round(time.Millisecond)))
+	}
+
+	return nil
+}
+
+// ChatStream sends the messages to the LLM, constraining the reply to the JSON schema if any, and passes its tokens to onToken as they arrive.
+// It returns the reply so far, and cancels the generation when onToken stops it early.
+func (c *Client) ChatStream(ctx context.Context, messages []Message, schema any, onToken OnTokenFunc) (string, error) {
+	input := chatInput{
+		Model:     c.model,
+		Messages:  messages,
+		Stream:    true,
+		Format:    schema,
+		Options:   &c.options,
+		KeepAlive: c.keepAlive,
+	}
+
+	var reply strings.Builder
+	stopped := false
+	err := c.stream(ctx, http.MethodPost, "/api/chat", input, c.timeout, func(line []byte) (bool, error) {
+		var chunk chatChunk
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return false, fmt.Errorf("%w: failed to decode chunk: %w", ErrInvalidResponse, err)
+		}
+
+		if chunk.Error != "" {
+			return false, fmt.Errorf("%w: %s", ErrRequestFailed, chunk.Error)
+		}
+
+		if chunk.Message.Content != "" {
+			reply.WriteString(chunk.Message.Content)
+			stopped = onToken(chunk.Message.Content, reply.String())
+		}
+
+		return stopped || chunk.Done, nil
+	})
+
+	if c.debug {
+		last := messages[len(messages)-1]
 		log.Println(fmt.Sprintf(
-			"prompt: %s, ollama response: %s",
-			strings.ReplaceAll(prompt, "\n", " "),
-			strings.ReplaceAll(parsed.Response, "\n", " "),
+			"%s: %s, ollama reply: %s, stopped early: %t",
+			last.Role,
+			strings.ReplaceAll(last.Content, "\n", " "),
+			strings.ReplaceAll(reply.String(), "\n", " "),
+			stopped,
 		))
 	}
 
-	result := parsed.Response
+	return reply.String(), err
+}
+
+// do sends the input to the endpoint, if any, and decodes the result.
+// It retries with backoff while the request fails temporarily, each attempt within the timeout, if any.
+func (c *Client) do(ctx context.Context, method string, endpoint string, input any, result any, timeout time.Duration) error {
+	return c.retry(ctx, method, endpoint, func() (bool, error) {
+		err := c.send(ctx, method, endpoint, input, result, timeout)
+		return temporary(err), err
+	})
+}
+
+// stream sends the input to the endpoint, and passes each line of the streamed result to onLine until it returns true.
+// It retries like do, but only until the first line, since the next attempt would repeat the lines.
+func (c *Client) stream(ctx context.Context, method string, endpoint string, input any, timeout time.Duration, onLine lineFunc) error {
+	return c.retry(ctx, method, endpoint, func() (bool, error) {
+		received := false
+		err := c.sendStream(ctx, method, endpoint, input, timeout, func(line []byte) (bool, error) {
+			received = true
+			return onLine(line)
+		})
+
+		return !received && temporary(err), err
+	})
+}
+
+// retry calls attempt with backoff while it fails and may be retried.
+func (c *Client) retry(ctx context.Context, method string, endpoint string, attempt func() (retryable bool, err error)) error {
+	delay := c.retryDelay
+
+	for i := 0; ; i++ {
+		retryable, err := attempt()
+		if err == nil || !retryable || i >= c.retries {
+			return err
+		}
+
+		if c.debug {
+			log.Println(fmt.Sprintf("retrying %s %s in %s: %s", method, endpoint, delay, err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrUnavailable, ctx.Err())
+		case <-time.After(delay):
+			delay *= 2
+		}
+	}
+}
+
+// lineFunc handles a line of a streamed result, and returns true to stop the stream.
+type lineFunc func(line []byte) (stop bool, err error)
+
+// send sends the input to the endpoint once, and decodes the result.
+func (c *Client) send(ctx context.Context, method string, endpoint string, input any, result any, timeout time.Duration) error {
+	return c.exchange(ctx, method, endpoint, input, timeout, func(body io.Reader) error {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("%w: failed to read response: %w", ErrUnavailable, err)
+		}
 
-	return result, nil
+		if err := json.Unmarshal(data, result); err != nil {
+			return fmt.Errorf("%w: failed to decode response: %w", ErrInvalidResponse, err)
+		}
+
+		return nil
+	})
 }
 
-// buildGenerateRequest builds a request for the generate endpoint.
-func (c *Client) buildGenerateRequest(ctx context.Context, input generateInput) (*http.Request, error) {
-	jsonBody, err := json.Marshal(input)
+// sendStream sends the input to the endpoint once, and passes each line of the streamed result to onLine until it returns true.
+// Stopping early closes the connection, which cancels the generation.
+func (c *Client) sendStream(ctx context.Context, method string, endpoint string, input any, timeout time.Duration, onLine lineFunc) error {
+	return c.exchange(ctx, method, endpoint, input, timeout, func(body io.Reader) error {
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			stop, err := onLine(line)
+			if err != nil || stop {
+				return err
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("%w: failed to read response: %w", ErrUnavailable, err)
+		}
+
+		return fmt.Errorf("%w: the response ended before it was done", ErrInvalidResponse)
+	})
+}
+
+// exchange sends the input to the endpoint once, within the timeout if any, and reads the successful response.
+func (c *Client) exchange(ctx context.Context, method string, endpoint string, input any, timeout time.Duration, read func(body io.Reader) error) error {
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, ErrTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	req, err := c.buildRequest(ctx, method, endpoint, input)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		// Only blame the timeout of the request, the caller knows when it cancelled.
+		if errors.Is(context.Cause(ctx), ErrTimeout) {
+			return fmt.Errorf("%w after %s: %s %s", ErrTimeout, timeout, method, endpoint)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		read = func(body io.Reader) error {
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return fmt.Errorf("%w: failed to read response: %w", ErrUnavailable, err)
+			}
+
+			return newAPIError(resp.StatusCode, data)
+		}
+	}
+
+	if err := read(resp.Body); err != nil {
+		if errors.Is(context.Cause(ctx), ErrTimeout) {
+			return fmt.Errorf("%w after %s: %s %s", ErrTimeout, timeout, method, endpoint)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// newAPIError creates the error of the response, with the `error` field of the body if any.
+func newAPIError(statusCode int, body []byte) *APIError {
+	var parsed errorResult
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error != "" {
+		return &APIError{StatusCode: statusCode, Message: parsed.Error}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > maxErrorBody {
+		message = message[:maxErrorBody] + "…"
+	}
+
+	return &APIError{StatusCode: statusCode, Message: message}
+}
+
+// temporary checks if the request may succeed when retried.
+func temporary(err error) bool {
+	if errors.Is(err, ErrUnavailable) {
+		return true
+	}
+
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Temporary()
+}
+
+// buildRequest builds a request for the endpoint, eg. `/api/generate`, without a body when the input is nil.
+func (c *Client) buildRequest(ctx context.Context, method string, endpoint string, input any) (*http.Request, error) {
+	var body io.Reader
+	if input != nil {
+		jsonBody, err := json.Marshal(input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		}
+
+		body = bytes.NewReader(jsonBody)
 	}
 
-	body := bytes.NewReader(jsonBody)
-	url := fmt.Sprintf("%s/api/generate", c.url)
+	url := fmt.Sprintf("%s%s", c.url, endpoint)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
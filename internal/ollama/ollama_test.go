@@ -0,0 +1,598 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubConfig is the configuration for the stub server.
+type stubConfig struct {
+	// Delay is how long the stub takes to respond, eg. to exceed the timeout of the client.
+	Delay time.Duration
+	// Failures is the number of requests answered with 503 Service Unavailable before succeeding.
+	Failures int
+	// Models are the pulled models, eg. `llama3:latest`.
+	Models []string
+	// Reply is the response of the chat endpoint.
+	Reply string
+	// StreamError fails the streamed replies after their tokens, instead of finishing them.
+	StreamError string
+	// TokenDelay is the delay between the tokens of the streamed replies.
+	TokenDelay time.Duration
+}
+
+// stubServer is an Ollama stand-in over HTTP, to test the client without a real Ollama server.
+type stubServer struct {
+	*httptest.Server
+
+	delay       time.Duration
+	models      []string
+	reply       string
+	streamError string
+	tokenDelay  time.Duration
+
+	mu          sync.Mutex
+	failures    int
+	requests    []string
+	times       []time.Time
+	connections int
+	cancelled   int
+}
+
+// newStubServer starts a stub server, closed when the test is done.
+func newStubServer(t *testing.T, cfg stubConfig) *stubServer {
+	t.Helper()
+
+	s := &stubServer{
+		delay:       cfg.Delay,
+		failures:    cfg.Failures,
+		models:      cfg.Models,
+		reply:       cfg.Reply,
+		streamError: cfg.StreamError,
+		tokenDelay:  cfg.TokenDelay,
+	}
+
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(s.handle))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.mu.Lock()
+			s.connections++
+			s.mu.Unlock()
+		}
+	}
+
+	s.Start()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Requests returns the requests received by the stub server, as `METHOD /path`, in order.
+func (s *stubServer) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.requests)
+}
+
+// Times returns when the requests were received, in order.
+func (s *stubServer) Times() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.times)
+}
+
+// Connections returns the number of connections the clients opened.
+func (s *stubServer) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connections
+}
+
+// Cancelled returns the number of streamed replies the clients stopped reading.
+func (s *stubServer) Cancelled() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cancelled
+}
+
+// handle answers the request like Ollama.
+func (s *stubServer) handle(w http.ResponseWriter, r *http.Request) {
+	request := fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	s.times = append(s.times, time.Now())
+	fail := s.failures > 0
+	if fail {
+		s.failures--
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-r.Context().Done():
+		return
+	case <-time.After(s.delay):
+	}
+
+	if fail {
+		writeJSON(w, http.StatusServiceUnavailable, errorResult{Error: "server busy, please try again"})
+		return
+	}
+
+	switch request {
+	case "GET /api/tags":
+		var tags tagsResult
+		for _, name := range s.models {
+			tags.Models = append(tags.Models, struct {
+				Name string `json:"name"`
+			}{Name: name})
+		}
+
+		writeJSON(w, http.StatusOK, tags)
+
+	case "POST /api/generate", "POST /api/chat":
+		var input struct {
+			Model  string `json:"model"`
+			Stream bool   `json:"stream"`
+		}
+
+		// Reading the whole body lets the server notice the client closing the connection.
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &input); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResult{Error: fmt.Sprintf("invalid request: %s", err)})
+			return
+		}
+
+		if !slices.ContainsFunc(s.models, func(name string) bool {
+			return name == input.Model || name == input.Model+":latest"
+		}) {
+			writeJSON(w, http.StatusNotFound, errorResult{Error: fmt.Sprintf("model %q not found, try pulling it first", input.Model)})
+			return
+		}
+
+		// Warming up generates nothing, and the chats are streamed.
+		if strings.HasSuffix(request, "generate") || !input.Stream {
+			writeJSON(w, http.StatusOK, generateResult{})
+			return
+		}
+
+		s.stream(w, r)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// stream writes the reply a few characters at a time, like the tokens of a model, until the client stops reading.
+func (s *stubServer) stream(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	flusher := w.(http.Flusher)
+
+	for _, token := range splitTokens(s.reply) {
+		select {
+		case <-r.Context().Done():
+			s.mu.Lock()
+			s.cancelled++
+			s.mu.Unlock()
+
+			return
+		case <-time.After(s.tokenDelay):
+		}
+
+		if err := encoder.Encode(chatChunk{Message: Message{Role: RoleAssistant, Content: token}}); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+
+	if s.streamError != "" {
+		encoder.Encode(chatChunk{Error: s.streamError})
+		return
+	}
+
+	encoder.Encode(chatChunk{Message: Message{Role: RoleAssistant}, Done: true})
+}
+
+// splitTokens splits the text into tokens of a few characters.
+func splitTokens(text string) []string {
+	const size = 4
+
+	runes := []rune(text)
+	tokens := make([]string, 0, len(runes)/size+1)
+	for start := 0; start < len(runes); start += size {
+		tokens = append(tokens, string(runes[start:min(start+size, len(runes))]))
+	}
+
+	return tokens
+}
+
+// writeJSON writes the value as the JSON body of the response.
+func writeJSON(w http.ResponseWriter, statusCode int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+// newTestClient creates a client of the server, for the `llama3` model.
+func newTestClient(t *testing.T, url string, cfg ClientConfig) *Client {
+	t.Helper()
+
+	cfg.Model = "llama3"
+	cfg.URL = url
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	return client
+}
+
+func TestAPIErrors(t *testing.T) {
+	long := strings.Repeat("x", maxErrorBody+10)
+
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantIs        error
+		wantNot       error
+		wantMessage   string
+		wantTemporary bool
+	}{
+		{
+			name:        "missing model",
+			status:      http.StatusNotFound,
+			body:        `{"error":"model \"llama3\" not found, try pulling it first"}`,
+			wantIs:      ErrModelNotFound,
+			wantNot:     ErrRequestFailed,
+			wantMessage: `model "llama3" not found, try pulling it first`,
+		},
+		{
+			name:        "wrong url",
+			status:      http.StatusNotFound,
+			body:        "404 page not found",
+			wantIs:      ErrRequestFailed,
+			wantNot:     ErrModelNotFound,
+			wantMessage: "404 page not found",
+		},
+		{
+			name:        "error field",
+			status:      http.StatusBadRequest,
+			body:        `{"error":"invalid format"}`,
+			wantIs:      ErrRequestFailed,
+			wantNot:     ErrModelNotFound,
+			wantMessage: "invalid format",
+		},
+		{
+			name:          "server error",
+			status:        http.StatusInternalServerError,
+			body:          `{"error":"out of memory"}`,
+			wantIs:        ErrRequestFailed,
+			wantMessage:   "out of memory",
+			wantTemporary: true,
+		},
+		{
+			name:          "rate limited",
+			status:        http.StatusTooManyRequests,
+			body:          "slow down",
+			wantIs:        ErrRequestFailed,
+			wantMessage:   "slow down",
+			wantTemporary: true,
+		},
+		{
+			name:        "long body",
+			status:      http.StatusBadGateway,
+			body:        long,
+			wantIs:      ErrRequestFailed,
+			wantMessage: long[:maxErrorBody] + "…",
+			// Bad gateways are temporary too.
+			wantTemporary: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+
+			err := newTestClient(t, server.URL, ClientConfig{}).CheckModel(context.Background())
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("CheckModel() error = %v, want an APIError", err)
+			}
+
+			if apiErr.StatusCode != tt.status || apiErr.Message != tt.wantMessage {
+				t.Errorf("CheckModel() error = %d %q, want %d %q", apiErr.StatusCode, apiErr.Message, tt.status, tt.wantMessage)
+			}
+
+			if !errors.Is(err, tt.wantIs) {
+				t.Errorf("CheckModel() error = %v, want %v", err, tt.wantIs)
+			}
+
+			if tt.wantNot != nil && errors.Is(err, tt.wantNot) {
+				t.Errorf("CheckModel() error = %v, want not %v", err, tt.wantNot)
+			}
+
+			if apiErr.Temporary() != tt.wantTemporary {
+				t.Errorf("Temporary() = %t, want %t", apiErr.Temporary(), tt.wantTemporary)
+			}
+		})
+	}
+}
+
+func TestCheckModel(t *testing.T) {
+	server := newStubServer(t, stubConfig{Models: []string{"mistral:latest", "llama3:latest"}})
+
+	if err := newTestClient(t, server.URL, ClientConfig{}).CheckModel(context.Background()); err != nil {
+		t.Errorf("CheckModel() failed: %v", err)
+	}
+
+	missing := newStubServer(t, stubConfig{Models: []string{"mistral:latest"}})
+
+	err := newTestClient(t, missing.URL, ClientConfig{}).CheckModel(context.Background())
+	if !errors.Is(err, ErrModelNotFound) || !strings.Contains(err.Error(), "mistral:latest") {
+		t.Errorf("CheckModel() error = %v, want %v listing the available models", err, ErrModelNotFound)
+	}
+}
+
+func TestStreamErrorField(t *testing.T) {
+	server := newStubServer(t, stubConfig{
+		Models:      []string{"llama3:latest"},
+		Reply:       `{"command":`,
+		StreamError: "model crashed",
+	})
+	client := newTestClient(t, server.URL, ClientConfig{Retries: 2})
+
+	reply, err := client.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "hi"}}, nil, func(string, string) bool {
+		return false
+	})
+
+	if !errors.Is(err, ErrRequestFailed) || !strings.Contains(err.Error(), "model crashed") {
+		t.Errorf("ChatStream() error = %v, want %v with the error field", err, ErrRequestFailed)
+	}
+
+	if reply != `{"command":` {
+		t.Errorf("ChatStream() = %q, want the reply so far", reply)
+	}
+
+	// The tokens were already passed on, retrying would repeat them.
+	if got := len(server.Requests()); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		timeout time.Duration
+		cancel  bool
+		wantErr error
+	}{
+		{name: "responds in time", delay: 0, timeout: time.Second},
+		{name: "times out", delay: time.Second, timeout: 50 * time.Millisecond, wantErr: ErrTimeout},
+		{name: "caller cancels", delay: time.Second, timeout: time.Minute, cancel: true, wantErr: context.Canceled},
+		{name: "no timeout", delay: 100 * time.Millisecond, timeout: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubServer(t, stubConfig{Delay: tt.delay, Models: []string{"llama3:latest"}})
+			client := newTestClient(t, server.URL, ClientConfig{Timeout: tt.timeout})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.cancel {
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err := client.CheckModel(ctx)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckModel() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// The caller's cancellation is not blamed on the timeout.
+			if tt.wantErr != ErrTimeout && errors.Is(err, ErrTimeout) {
+				t.Errorf("CheckModel() error = %v, want not %v", err, ErrTimeout)
+			}
+
+			if elapsed := time.Since(start); tt.wantErr != nil && elapsed > tt.delay/2 {
+				t.Errorf("CheckModel() took %s, want it to stop before the response", elapsed)
+			}
+		})
+	}
+}
+
+func TestWarmUpIgnoresTimeout(t *testing.T) {
+	server := newStubServer(t, stubConfig{Delay: 100 * time.Millisecond, Models: []string{"llama3:latest"}})
+	client := newTestClient(t, server.URL, ClientConfig{Timeout: 10 * time.Millisecond})
+
+	if err := client.WarmUp(context.Background()); err != nil {
+		t.Errorf("WarmUp() failed: %v", err)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	const retryDelay = 20 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		failures     int
+		retries      int
+		models       []string
+		wantErr      error
+		wantRequests int
+	}{
+		{name: "no failures", failures: 0, retries: 2, wantRequests: 1},
+		{name: "recovers", failures: 2, retries: 2, wantRequests: 3},
+		{name: "gives up", failures: 3, retries: 2, wantErr: ErrRequestFailed, wantRequests: 3},
+		{name: "no retries", failures: 1, retries: 0, wantErr: ErrRequestFailed, wantRequests: 1},
+		{name: "missing model is not retried", retries: 2, models: []string{}, wantErr: ErrModelNotFound, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			models := []string{"llama3:latest"}
+			if tt.models != nil {
+				models = tt.models
+			}
+
+			server := newStubServer(t, stubConfig{Failures: tt.failures, Models: models})
+			client := newTestClient(t, server.URL, ClientConfig{Retries: tt.retries, RetryDelay: retryDelay})
+
+			err := client.WarmUp(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WarmUp() error = %v, want %v", err, tt.wantErr)
+			}
+
+			times := server.Times()
+			if len(times) != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", len(times), tt.wantRequests)
+			}
+
+			// The delay doubles on each retry.
+			for i := 1; i < len(times); i++ {
+				if gap, want := times[i].Sub(times[i-1]), retryDelay<<(i-1); gap < want {
+					t.Errorf("retry %d after %s, want at least %s", i, gap, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	server := newStubServer(t, stubConfig{Failures: 10, Models: []string{"llama3:latest"}})
+	client := newTestClient(t, server.URL, ClientConfig{Retries: 5, RetryDelay: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := client.WarmUp(ctx); !errors.Is(err, ErrUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WarmUp() error = %v, want %v with the context error", err, ErrUnavailable)
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := newTestClient(t, server.URL, ClientConfig{Retries: 1, RetryDelay: time.Millisecond})
+
+	if err := client.CheckModel(context.Background()); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("CheckModel() error = %v, want %v", err, ErrUnavailable)
+	}
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	server := newStubServer(t, stubConfig{
+		Models: []string{"llama3:latest"},
+		Reply:  `{"command":"do_nothing","confidence":1,"args":{}}`,
+	})
+	client := newTestClient(t, server.URL, ClientConfig{})
+	ctx := context.Background()
+
+	for range 3 {
+		if err := client.CheckModel(ctx); err != nil {
+			t.Fatalf("CheckModel() failed: %v", err)
+		}
+
+		if err := client.WarmUp(ctx); err != nil {
+			t.Fatalf("WarmUp() failed: %v", err)
+		}
+
+		if _, err := client.ChatStream(ctx, []Message{{Role: RoleUser, Content: "hi"}}, nil, func(string, string) bool {
+			return false
+		}); err != nil {
+			t.Fatalf("ChatStream() failed: %v", err)
+		}
+	}
+
+	if got := len(server.Requests()); got != 9 {
+		t.Errorf("requests = %d, want 9", got)
+	}
+
+	if got := server.Connections(); got != 1 {
+		t.Errorf("connections = %d, want 1", got)
+	}
+}
+
+func TestChatStream(t *testing.T) {
+	const reply = `{"command":"pause_video","confidence":0.9,"args":{}}`
+
+	tests := []struct {
+		name          string
+		failures      int
+		stopAfter     string
+		want          string
+		wantRequests  int
+		wantCancelled bool
+	}{
+		{name: "whole reply", want: reply, wantRequests: 1},
+		{name: "retries before the first token", failures: 2, want: reply, wantRequests: 3},
+		{name: "stops early", stopAfter: `"pause_video"`, want: `{"command":"pause_video"`, wantRequests: 1, wantCancelled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newStubServer(t, stubConfig{
+				Failures:   tt.failures,
+				Models:     []string{"llama3:latest"},
+				Reply:      reply,
+				TokenDelay: 10 * time.Millisecond,
+			})
+			client := newTestClient(t, server.URL, ClientConfig{Retries: 2, RetryDelay: time.Millisecond})
+
+			var tokens []string
+			got, err := client.ChatStream(context.Background(), []Message{{Role: RoleUser, Content: "pause"}}, nil, func(token string, soFar string) bool {
+				tokens = append(tokens, token)
+				return tt.stopAfter != "" && strings.Contains(soFar, tt.stopAfter)
+			})
+			if err != nil {
+				t.Fatalf("ChatStream() failed: %v", err)
+			}
+
+			if got != tt.want || strings.Join(tokens, "") != tt.want {
+				t.Errorf("ChatStream() = %q with tokens %q, want %q", got, tokens, tt.want)
+			}
+
+			if requests := len(server.Requests()); requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+
+			// Stopping early closes the connection, which stops the generation.
+			deadline := time.Now().Add(time.Second)
+			for tt.wantCancelled && server.Cancelled() == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			if cancelled := server.Cancelled() > 0; cancelled != tt.wantCancelled {
+				t.Errorf("cancelled = %t, want %t", cancelled, tt.wantCancelled)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+// Package protocol provides the wire protocol between the listener and the executor.
+//
+// Messages are newline-delimited JSON envelopes. The executor also accepts the
+// plain-text form `name key=value`, so it can be tested with `nc`.
+package protocol
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// Version is the version of the wire protocol.
+const Version = 1
+
+// Request is the envelope of a command sent to the executor.
+type Request struct {
+	// Version is the version of the wire protocol.
+	Version int `json:"version"`
+	// ID identifies the request, it is echoed in the response.
+	ID string `json:"id"`
+	// Command is the name of the command.
+	Command string `json:"command"`
+	// Args are the raw arguments of the command.
+	Args command.Args `json:"args,omitempty"`
+	// Timestamp is when the request was created.
+	Timestamp time.Time `json:"timestamp"`
+	// Source is the sender of the request, eg. `listener`.
+	Source string `json:"source"`
+}
+
+// Response is the envelope of the result of a command.
+type Response struct {
+	// Version is the version of the wire protocol.
+	Version int `json:"version"`
+	// ID is the ID of the request.
+	ID string `json:"id"`
+	// OK is true when the command was executed.
+	OK bool `json:"ok"`
+	// Error is the reason the command failed, when not OK.
+	Error string `json:"error,omitempty"`
+	// Result is the output of the command, if any.
+	Result string `json:"result,omitempty"`
+	// LatencyMS is how long the executor took to handle the command.
+	LatencyMS int64 `json:"latency_ms"`
+}
+
+// NewRequest creates a new request for the invocation.
+func NewRequest(inv command.Invocation, source string) Request {
+	return Request{
+		Version:   Version,
+		ID:        newID(),
+		Command:   inv.Name,
+		Args:      inv.Args,
+		Timestamp: time.Now().UTC(),
+		Source:    source,
+	}
+}
+
+// NewResponse creates a new response for the request.
+func NewResponse(id string, result string, err error, latency time.Duration) Response {
+	resp := Response{
+		Version:   Version,
+		ID:        id,
+		OK:        err == nil,
+		Result:    result,
+		LatencyMS: latency.Milliseconds(),
+	}
+
+	if err != nil {
+		resp.Error = err.Error()
+	}
+
+	return resp
+}
+
+// Invocation returns the command invocation of the request.
+func (r Request) Invocation() command.Invocation {
+	return command.Invocation{
+		Name: r.Command,
+		Args: r.Args,
+	}
+}
+
+// DecodeRequest decodes a JSON envelope, or a plain-text `name key=value` line.
+func DecodeRequest(line string) (Request, error) {
+	line = strings.TrimSpace(line)
+
+	// Fallback to the plain-text form, eg. `echo pause_video | nc ...`.
+	if !strings.HasPrefix(line, "{") {
+		inv, err := command.ParseInvocation(line)
+		if err != nil {
+			return Request{}, fmt.Errorf("failed to parse plain-text request: %w", err)
+		}
+
+		return Request{
+			Version:   Version,
+			ID:        newID(),
+			Command:   inv.Name,
+			Args:      inv.Args,
+			Timestamp: time.Now().UTC(),
+			Source:    "plain-text",
+		}, nil
+	}
+
+	var req Request
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return Request{}, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	if req.Version != Version {
+		return req, fmt.Errorf("unsupported protocol version %d, expected %d", req.Version, Version)
+	}
+
+	if req.Command == "" {
+		return req, fmt.Errorf("request %q has no command", req.ID)
+	}
+
+	return req, nil
+}
+
+// DecodeResponse decodes a JSON envelope.
+func DecodeResponse(line []byte) (Response, error) {
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return Response{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if resp.Version != Version {
+		return resp, fmt.Errorf("unsupported protocol version %d, expected %d", resp.Version, Version)
+	}
+
+	return resp, nil
+}
+
+// Encode encodes the envelope as a single JSON line.
+func Encode(envelope any) ([]byte, error) {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode envelope: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
+
+// newID returns a random request ID.
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
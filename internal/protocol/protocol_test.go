@@ -0,0 +1,204 @@
+package protocol
+
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantID     string
+		wantName   string
+		wantArgs   command.Args
+		wantSource string
+	}{
+		{
+			name:       "json envelope",
+			line:       `{"version":1,"id":"42","command":"set_volume","args":{"level":"40"},"timestamp":"2025-01-02T03:04:05Z","source":"listener"}`,
+			wantID:     "42",
+			wantName:   "set_volume",
+			wantArgs:   command.Args{"level": "40"},
+			wantSource: "listener",
+		},
+		{
+			name:       "json envelope without args",
+			line:       `{"version":1,"id":"7","command":"pause_video","source":"listener"}` + "\n",
+			wantID:     "7",
+			wantName:   "pause_video",
+			wantSource: "listener",
+		},
+		{
+			name:       "unknown fields are ignored",
+			line:       `{"version":1,"id":"8","command":"pause_video","source":"listener","priority":"high"}`,
+			wantID:     "8",
+			wantName:   "pause_video",
+			wantSource: "listener",
+		},
+		{
+			name:       "plain text",
+			line:       "pause_video\n",
+			wantName:   "pause_video",
+			wantArgs:   command.Args{},
+			wantSource: "plain-text",
+		},
+		{
+			name:       "plain text with quoted args",
+			line:       `  type_text text="hello world" `,
+			wantName:   "type_text",
+			wantArgs:   command.Args{"text": "hello world"},
+			wantSource: "plain-text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := DecodeRequest(tt.line)
+			if err != nil {
+				t.Fatalf("DecodeRequest(%q) failed: %v", tt.line, err)
+			}
+
+			if req.Version != Version || req.Command != tt.wantName || req.Source != tt.wantSource || !maps.Equal(req.Args, tt.wantArgs) {
+				t.Errorf("DecodeRequest(%q) = %+v, want %s %v from %s", tt.line, req, tt.wantName, tt.wantArgs, tt.wantSource)
+			}
+
+			// Plain-text requests get a fresh ID, the envelopes keep theirs.
+			if tt.wantID != "" && req.ID != tt.wantID || tt.wantID == "" && len(req.ID) != 16 {
+				t.Errorf("DecodeRequest(%q) ID = %q, want %q", tt.line, req.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+		wantID  string
+	}{
+		{name: "malformed json", line: `{"version":1,"command":`, wantErr: "failed to decode request"},
+		{name: "wrong field type", line: `{"version":"1","command":"pause_video"}`, wantErr: "failed to decode request"},
+		{name: "unsupported version", line: `{"version":2,"id":"9","command":"pause_video"}`, wantErr: "unsupported protocol version 2", wantID: "9"},
+		{name: "missing version", line: `{"id":"9","command":"pause_video"}`, wantErr: "unsupported protocol version 0", wantID: "9"},
+		{name: "missing command", line: `{"version":1,"id":"10"}`, wantErr: `request "10" has no command`, wantID: "10"},
+		{name: "empty line", line: "  \n", wantErr: "failed to parse plain-text request"},
+		{name: "plain text without key", line: "set_volume 40", wantErr: "failed to parse plain-text request"},
+		{name: "plain text unterminated quote", line: `type_text text="hello`, wantErr: "failed to parse plain-text request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := DecodeRequest(tt.line)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("DecodeRequest(%q) error = %v, want %q", tt.line, err, tt.wantErr)
+			}
+
+			// The ID of a decoded envelope is kept, to answer the request with its error.
+			if req.ID != tt.wantID {
+				t.Errorf("DecodeRequest(%q) ID = %q, want %q", tt.line, req.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestPlainTextRequestIsInvalidArgs(t *testing.T) {
+	if _, err := DecodeRequest("set_volume 40"); !errors.Is(err, command.ErrInvalidArgs) {
+		t.Errorf("DecodeRequest() error = %v, want %v", err, command.ErrInvalidArgs)
+	}
+}
+
+func TestRequestRoundTrip(t *testing.T) {
+	req := NewRequest(command.Invocation{Name: "seek_forward", Args: command.Args{"seconds": "30s"}}, "listener")
+
+	data, err := Encode(req)
+	if err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+
+	if !strings.HasSuffix(string(data), "}\n") || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("Encode() = %q, want a single JSON line", data)
+	}
+
+	got, err := DecodeRequest(string(data))
+	if err != nil {
+		t.Fatalf("DecodeRequest(%q) failed: %v", data, err)
+	}
+
+	if got.ID != req.ID || !got.Timestamp.Equal(req.Timestamp) || got.Invocation().String() != "seek_forward seconds=30s" {
+		t.Errorf("DecodeRequest(Encode(%+v)) = %+v", req, got)
+	}
+}
+
+func TestResponseEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		resp   Response
+		want   string
+		wantOK bool
+	}{
+		{
+			name: "success",
+			resp: NewResponse("1", "paused vlc", nil, 1500*time.Microsecond),
+			want: `{"version":1,"id":"1","ok":true,"result":"paused vlc","latency_ms":1}` + "\n",
+		},
+		{
+			name: "success without result",
+			resp: NewResponse("2", "", nil, 0),
+			want: `{"version":1,"id":"2","ok":true,"latency_ms":0}` + "\n",
+		},
+		{
+			name: "failure",
+			resp: NewResponse("3", "", errors.New("unknown command"), 2*time.Millisecond),
+			want: `{"version":1,"id":"3","ok":false,"error":"unknown command","latency_ms":2}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Encode(tt.resp)
+			if err != nil {
+				t.Fatalf("Encode() failed: %v", err)
+			}
+
+			if string(data) != tt.want {
+				t.Errorf("Encode(%+v) = %q, want %q", tt.resp, data, tt.want)
+			}
+
+			got, err := DecodeResponse(data)
+			if err != nil {
+				t.Fatalf("DecodeResponse(%q) failed: %v", data, err)
+			}
+
+			if got != tt.resp {
+				t.Errorf("DecodeResponse(%q) = %+v, want %+v", data, got, tt.resp)
+			}
+		})
+	}
+}
+
+func TestDecodeResponseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+	}{
+		{name: "malformed json", line: `{"version":1,"ok":`, wantErr: "failed to decode response"},
+		{name: "plain text", line: "ok", wantErr: "failed to decode response"},
+		{name: "unsupported version", line: `{"version":2,"id":"1","ok":true}`, wantErr: "unsupported protocol version 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeResponse([]byte(tt.line)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DecodeResponse(%q) error = %v, want %q", tt.line, err, tt.wantErr)
+			}
+		})
+	}
+}
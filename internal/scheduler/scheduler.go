@@ -0,0 +1,327 @@
+// Package scheduler provides a scheduler that runs commands later, and remembers them across restarts.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// ErrNotStarted is returned when scheduling before the scheduler is started.
+var ErrNotStarted = errors.New("scheduler is not started")
+
+// DispatchFunc runs the scheduled command.
+type DispatchFunc func(ctx context.Context, inv command.Invocation) (string, error)
+
+// Config is the configuration for the scheduler.
+type Config struct {
+	// Debug enables logging the scheduled and run commands.
+	Debug bool
+	// Dispatch runs the commands when they are due.
+	Dispatch DispatchFunc
+	// MaxLate is how late a command missed while stopped still runs, older ones are dropped.
+	MaxLate time.Duration
+	// Path is the file the scheduled commands are saved to, empty does not save them.
+	Path string
+}
+
+// Job is a scheduled command.
+type Job struct {
+	// ID is the short identifier of the job, eg. `3`.
+	ID string `json:"id"`
+	// Command is the invocation of the command, eg. `pause_video`.
+	Command string `json:"command"`
+	// At is when the command runs.
+	At time.Time `json:"at"`
+}
+
+// Scheduler runs commands when they are due.
+type Scheduler struct {
+	debug    bool
+	dispatch DispatchFunc
+	maxLate  time.Duration
+	path     string
+
+	mu     sync.Mutex
+	ctx    context.Context
+	jobs   map[string]Job
+	timers map[string]*time.Timer
+	lastID int
+}
+
+// state is the saved state of the scheduler.
+type state struct {
+	LastID int   `json:"last_id"`
+	Jobs   []Job `json:"jobs"`
+}
+
+// New creates a new scheduler.
+func New(cfg Config) (*Scheduler, error) {
+	if cfg.Dispatch == nil {
+		return nil, fmt.Errorf("dispatch is required")
+	}
+
+	if cfg.MaxLate < 0 {
+		return nil, fmt.Errorf("max late must not be negative")
+	}
+
+	return &Scheduler{
+		debug:    cfg.Debug,
+		dispatch: cfg.Dispatch,
+		maxLate:  cfg.MaxLate,
+		path:     cfg.Path,
+		jobs:     map[string]Job{},
+		timers:   map[string]*time.Timer{},
+	}, nil
+}
+
+// Start loads the saved commands and runs them when due, until the context is done.
+// The commands run with the context, and stay saved when it is done.
+func (s *Scheduler) Start(ctx context.Context) error {
+	saved, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx = ctx
+	s.lastID = saved.LastID
+
+	now := time.Now()
+	for _, job := range saved.Jobs {
+		if late := now.Sub(job.At); late > s.maxLate {
+			log.Println(fmt.Sprintf("dropped scheduled command %q: missed by %s", job.Command, late.Round(time.Second)))
+			continue
+		}
+
+		s.add(job)
+	}
+
+	if err := s.save(); err != nil {
+		return err
+	}
+
+	// Stop the timers, without forgetting the commands.
+	go func() {
+		<-ctx.Done()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		for _, timer := range s.timers {
+			timer.Stop()
+		}
+	}()
+
+	return nil
+}
+
+// Schedule schedules the command to run at the given time.
+func (s *Scheduler) Schedule(inv command.Invocation, at time.Time) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		return Job{}, ErrNotStarted
+	}
+
+	s.lastID++
+	job := Job{
+		ID:      strconv.Itoa(s.lastID),
+		Command: inv.String(),
+		At:      at,
+	}
+
+	s.add(job)
+
+	// Unschedule the job when it can't be saved, rather than run a command the user was told failed.
+	if err := s.save(); err != nil {
+		s.timers[job.ID].Stop()
+		delete(s.timers, job.ID)
+		delete(s.jobs, job.ID)
+		s.lastID--
+
+		return Job{}, err
+	}
+
+	if s.debug {
+		log.Println(fmt.Sprintf("scheduled command %q at %s", job.Command, job.At.Format(time.DateTime)))
+	}
+
+	return job, nil
+}
+
+// List returns the scheduled commands, the soonest first.
+func (s *Scheduler) List() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+
+	slices.SortFunc(jobs, func(a, b Job) int {
+		return a.At.Compare(b.At)
+	})
+
+	return jobs
+}
+
+// Cancel cancels the scheduled commands matching the filter, and returns them.
+// A nil filter cancels all of them.
+func (s *Scheduler) Cancel(filter func(Job) bool) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cancelled []Job
+	for id, job := range s.jobs {
+		if filter != nil && !filter(job) {
+			continue
+		}
+
+		s.timers[id].Stop()
+		delete(s.timers, id)
+		delete(s.jobs, id)
+
+		cancelled = append(cancelled, job)
+	}
+
+	if len(cancelled) == 0 {
+		return nil, nil
+	}
+
+	return cancelled, s.save()
+}
+
+// add tracks the job and starts its timer, due jobs run right away.
+func (s *Scheduler) add(job Job) {
+	s.jobs[job.ID] = job
+	s.timers[job.ID] = time.AfterFunc(time.Until(job.At), func() {
+		s.run(job.ID)
+	})
+}
+
+// run runs the job, unless it was cancelled.
+func (s *Scheduler) run(id string) {
+	s.mu.Lock()
+
+	job, ok := s.jobs[id]
+	if !ok || s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return
+	}
+
+	delete(s.jobs, id)
+	delete(s.timers, id)
+
+	if err := s.save(); err != nil {
+		log.Println(fmt.Sprintf("error saving scheduled commands: %v", err))
+	}
+
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	inv, err := command.ParseInvocation(job.Command)
+	if err != nil {
+		log.Println(fmt.Sprintf("error parsing scheduled command %q: %v", job.Command, err))
+		return
+	}
+
+	result, err := s.dispatch(ctx, inv)
+	if err != nil {
+		log.Println(fmt.Sprintf("error running scheduled command %q: %v", job.Command, err))
+		return
+	}
+
+	if s.debug {
+		log.Println(fmt.Sprintf("ran scheduled command %q: %q", job.Command, result))
+	}
+}
+
+// load reads the saved state, missing is empty.
+func (s *Scheduler) load() (state, error) {
+	var saved state
+	if s.path == "" {
+		return saved, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return saved, nil
+	}
+
+	if err != nil {
+		return saved, fmt.Errorf("failed to read scheduled commands: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return saved, fmt.Errorf("failed to decode scheduled commands %q: %w", s.path, err)
+	}
+
+	return saved, nil
+}
+
+// save writes the state, replacing the file at once so a crash never leaves it half written.
+func (s *Scheduler) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	saved := state{
+		LastID: s.lastID,
+		Jobs:   make([]Job, 0, len(s.jobs)),
+	}
+
+	for _, job := range s.jobs {
+		saved.Jobs = append(saved.Jobs, job)
+	}
+
+	slices.SortFunc(saved.Jobs, func(a, b Job) int {
+		return a.At.Compare(b.At)
+	})
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode scheduled commands: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write scheduled commands: %w", err)
+	}
+
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write scheduled commands: %w", err)
+	}
+
+	return nil
+}
+
+// Next returns the next occurrence of the time of day, given as the duration since midnight.
+func Next(now time.Time, clock time.Duration) time.Time {
+	hour, minute := int(clock/time.Hour), int(clock%time.Hour/time.Minute)
+
+	at := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+
+	return at
+}
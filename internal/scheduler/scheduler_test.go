@@ -0,0 +1,295 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/command"
+)
+
+// dispatched records the dispatched commands.
+type dispatched chan string
+
+func (d dispatched) dispatch(_ context.Context, inv command.Invocation) (string, error) {
+	d <- inv.String()
+	return "", nil
+}
+
+// newTestScheduler creates a started scheduler saving to the path.
+func newTestScheduler(t *testing.T, path string, maxLate time.Duration) (*Scheduler, dispatched) {
+	t.Helper()
+
+	d := make(dispatched, 8)
+
+	s, err := New(Config{Dispatch: d.dispatch, MaxLate: maxLate, Path: path})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	return s, d
+}
+
+// commands returns the commands of the jobs.
+func commands(jobs []Job) []string {
+	cmds := make([]string, len(jobs))
+	for i, job := range jobs {
+		cmds[i] = job.Command
+	}
+
+	return cmds
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler", "jobs.json")
+	now := time.Now()
+
+	s, _ := newTestScheduler(t, path, time.Minute)
+	for _, job := range []struct {
+		inv command.Invocation
+		at  time.Time
+	}{
+		{inv: command.Invocation{Name: "pause_video"}, at: now.Add(2 * time.Hour)},
+		{inv: command.Invocation{Name: "set_volume", Args: command.Args{"level": "20"}}, at: now.Add(time.Hour)},
+	} {
+		if _, err := s.Schedule(job.inv, job.at); err != nil {
+			t.Fatalf("Schedule(%s) failed: %v", job.inv, err)
+		}
+	}
+
+	// A restarted executor loads the jobs, and keeps numbering them.
+	restarted, _ := newTestScheduler(t, path, time.Minute)
+
+	want := s.List()
+	got := restarted.List()
+	if !slices.EqualFunc(got, want, func(a, b Job) bool {
+		return a.ID == b.ID && a.Command == b.Command && a.At.Equal(b.At)
+	}) {
+		t.Errorf("List() after restart = %+v, want %+v", got, want)
+	}
+
+	if cmds := commands(got); !slices.Equal(cmds, []string{"set_volume level=20", "pause_video"}) {
+		t.Errorf("List() = %q, want the soonest first", cmds)
+	}
+
+	job, err := restarted.Schedule(command.Invocation{Name: "play_video"}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Schedule() after restart failed: %v", err)
+	}
+
+	if job.ID != "3" {
+		t.Errorf("Schedule() after restart ID = %q, want %q", job.ID, "3")
+	}
+}
+
+func TestStartMissedJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	now := time.Now()
+
+	// The executor was down while the first two jobs were due.
+	data, err := json.Marshal(state{
+		LastID: 3,
+		Jobs: []Job{
+			{ID: "1", Command: "pause_video", At: now.Add(-time.Hour)},
+			{ID: "2", Command: "mute_video", At: now.Add(-time.Minute)},
+			{ID: "3", Command: "play_video", At: now.Add(time.Hour)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode state: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write state: %v", err)
+	}
+
+	s, d := newTestScheduler(t, path, 5*time.Minute)
+
+	// The slightly late job runs right away, the one late by more than max late is dropped.
+	select {
+	case cmd := <-d:
+		if cmd != "mute_video" {
+			t.Errorf("dispatched %q, want %q", cmd, "mute_video")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the late job never ran")
+	}
+
+	select {
+	case cmd := <-d:
+		t.Errorf("dispatched %q, want only the late job", cmd)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if cmds := commands(s.List()); !slices.Equal(cmds, []string{"play_video"}) {
+		t.Errorf("List() = %q, want %q", cmds, []string{"play_video"})
+	}
+
+	// The dropped and the run jobs are not saved anymore.
+	restarted, _ := newTestScheduler(t, path, 5*time.Minute)
+	if cmds := commands(restarted.List()); !slices.Equal(cmds, []string{"play_video"}) {
+		t.Errorf("List() after restart = %q, want %q", cmds, []string{"play_video"})
+	}
+}
+
+func TestScheduleRuns(t *testing.T) {
+	s, d := newTestScheduler(t, "", 0)
+
+	if _, err := s.Schedule(command.Invocation{Name: "pause_video"}, time.Now().Add(20*time.Millisecond)); err != nil {
+		t.Fatalf("Schedule() failed: %v", err)
+	}
+
+	select {
+	case cmd := <-d:
+		if cmd != "pause_video" {
+			t.Errorf("dispatched %q, want %q", cmd, "pause_video")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the job never ran")
+	}
+
+	if jobs := s.List(); len(jobs) != 0 {
+		t.Errorf("List() after running = %+v, want none", jobs)
+	}
+}
+
+func TestScheduleNotStarted(t *testing.T) {
+	s, err := New(Config{Dispatch: make(dispatched).dispatch})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if _, err := s.Schedule(command.Invocation{Name: "pause_video"}, time.Now()); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Schedule() error = %v, want %v", err, ErrNotStarted)
+	}
+}
+
+func TestScheduleRollsBackWhenSaveFails(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "scheduler")
+	s, d := newTestScheduler(t, filepath.Join(dir, "jobs.json"), time.Minute)
+
+	// Replace the directory with a file, so the jobs can't be saved.
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("failed to remove directory: %v", err)
+	}
+
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := s.Schedule(command.Invocation{Name: "pause_video"}, time.Now().Add(20*time.Millisecond)); err == nil {
+		t.Fatalf("Schedule() succeeded, want an error")
+	}
+
+	if jobs := s.List(); len(jobs) != 0 {
+		t.Errorf("List() = %+v, want the failed job unscheduled", jobs)
+	}
+
+	select {
+	case cmd := <-d:
+		t.Errorf("dispatched %q, want the failed job unscheduled", cmd)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestListCancel(t *testing.T) {
+	s, _ := newTestScheduler(t, filepath.Join(t.TempDir(), "jobs.json"), time.Minute)
+	now := time.Now()
+
+	for i, name := range []string{"pause_video", "mute_video", "pause_video"} {
+		if _, err := s.Schedule(command.Invocation{Name: name}, now.Add(time.Duration(3-i)*time.Hour)); err != nil {
+			t.Fatalf("Schedule(%s) failed: %v", name, err)
+		}
+	}
+
+	if cmds := commands(s.List()); !slices.Equal(cmds, []string{"pause_video", "mute_video", "pause_video"}) {
+		t.Errorf("List() = %q, want the soonest first", cmds)
+	}
+
+	cancelled, err := s.Cancel(func(job Job) bool { return job.Command == "pause_video" })
+	if err != nil {
+		t.Fatalf("Cancel(pause_video) failed: %v", err)
+	}
+
+	if len(cancelled) != 2 {
+		t.Errorf("Cancel(pause_video) = %+v, want 2 jobs", cancelled)
+	}
+
+	if cmds := commands(s.List()); !slices.Equal(cmds, []string{"mute_video"}) {
+		t.Errorf("List() after Cancel(pause_video) = %q, want %q", cmds, []string{"mute_video"})
+	}
+
+	if cancelled, err := s.Cancel(func(job Job) bool { return job.Command == "play_video" }); err != nil || cancelled != nil {
+		t.Errorf("Cancel(play_video) = %+v, %v, want nothing", cancelled, err)
+	}
+
+	if cancelled, err := s.Cancel(nil); err != nil || len(cancelled) != 1 {
+		t.Errorf("Cancel(nil) = %+v, %v, want the last job", cancelled, err)
+	}
+
+	if jobs := s.List(); len(jobs) != 0 {
+		t.Errorf("List() after Cancel(nil) = %+v, want none", jobs)
+	}
+}
+
+func TestNext(t *testing.T) {
+	eightPM := 20 * time.Hour
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		clock time.Duration
+		want  time.Time
+	}{
+		{
+			name:  "later today",
+			now:   time.Date(2025, 3, 14, 15, 0, 0, 0, time.UTC),
+			clock: eightPM,
+			want:  time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "passed rolls to tomorrow",
+			now:   time.Date(2025, 3, 14, 21, 30, 0, 0, time.UTC),
+			clock: eightPM,
+			want:  time.Date(2025, 3, 15, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "now rolls to tomorrow",
+			now:   time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC),
+			clock: eightPM,
+			want:  time.Date(2025, 3, 15, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "rolls to the next month",
+			now:   time.Date(2025, 1, 31, 21, 0, 0, 0, time.UTC),
+			clock: eightPM,
+			want:  time.Date(2025, 2, 1, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "minutes",
+			now:   time.Date(2025, 3, 14, 7, 0, 0, 0, time.UTC),
+			clock: 7*time.Hour + 30*time.Minute,
+			want:  time.Date(2025, 3, 14, 7, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Next(tt.now, tt.clock); !got.Equal(tt.want) {
+				t.Errorf("Next(%s, %s) = %s, want %s", tt.now, tt.clock, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,7 +11,8 @@ import (
 )
 
 // OnMessageFunc is the callback for processing messages.
-type OnMessageFunc func(ctx context.Context, msg string) error
+// The reply, if not empty, is written back on the connection followed by a newline.
+type OnMessageFunc func(ctx context.Context, msg string) (reply string, err error)
 
 // TCPServerConfig is the configuration for the TCP server.
 type TCPServerConfig struct {
@@ -105,9 +106,38 @@ func (s *TCPServer) handleConnection(ctx context.Context, conn net.Conn) {
 	for scanner.Scan() {
 		msg := strings.TrimSpace(scanner.Text())
 
-		if err := s.onMessage(ctx, msg); err != nil {
-			s.stop(fmt.Sprintf("message error: %s", err))
+		// Skip empty lines, eg. keep-alives.
+		if msg == "" {
+			continue
+		}
+
+		// Message errors only fail the message, never the server.
+		reply, err := s.handleMessage(ctx, msg)
+		if err != nil {
+			log.Println(fmt.Sprintf("message error: %s", err))
+		}
+
+		if reply == "" {
+			continue
+		}
+
+		if _, err := conn.Write([]byte(strings.TrimSuffix(reply, "\n") + "\n")); err != nil {
+			if s.debug {
+				log.Println(fmt.Sprintf("failed to write reply: %s", err))
+			}
+
 			return
 		}
 	}
 }
+
+// HandleMessage calls the message callback, recovering from panics.
+func (s *TCPServer) handleMessage(ctx context.Context, msg string) (reply string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("message handler panicked: %v", r)
+		}
+	}()
+
+	return s.onMessage(ctx, msg)
+}
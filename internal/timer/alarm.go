@@ -0,0 +1,206 @@
+package timer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Alarm backends.
+const (
+	// BackendFFplay plays the alarm on the speakers using ffplay.
+	BackendFFplay = "ffplay"
+	// BackendWAV writes the alarm to a WAV file, eg. for headless runs or another player.
+	BackendWAV = "wav"
+)
+
+// Alarm beeps.
+const (
+	// beepFrequency is the pitch of the beeps, in Hz.
+	beepFrequency = 880
+	// beepVolume is the amplitude of the beeps, in (0, 1].
+	beepVolume = 0.4
+	// sampleRate is the sample rate of the generated alarm, in Hz.
+	sampleRate = 16000
+)
+
+// beepExpr is the ffmpeg expression of the beeps, half a second every second.
+var beepExpr = fmt.Sprintf("aevalsrc=%v*sin(2*PI*%d*t)*lt(mod(t\\,1)\\,0.5):s=%d", beepVolume, beepFrequency, sampleRate)
+
+// Alarm rings until the context is done.
+type Alarm interface {
+	// Ring rings the alarm, and blocks until the context is done.
+	Ring(ctx context.Context) error
+}
+
+// AlarmConfig is the configuration for the alarm.
+type AlarmConfig struct {
+	// Backend is the backend of the alarm, eg. `ffplay` or `wav`.
+	Backend string
+	// Binary is the ffplay binary, empty defaults to `ffplay` in PATH.
+	Binary string
+	// Debug enables logging the alarm.
+	Debug bool
+	// Duration is how long the WAV alarm is.
+	Duration time.Duration
+	// Output is the WAV file the alarm is written to.
+	Output string
+	// Sound is the sound file played by ffplay in a loop, empty plays beeps.
+	Sound string
+}
+
+// NewAlarm creates the alarm for the backend.
+// Without ffplay, it falls back to the WAV alarm, or to only logging the alarm without an output file.
+func NewAlarm(cfg AlarmConfig) (Alarm, error) {
+	switch cfg.Backend {
+	case BackendFFplay:
+		binary := cfg.Binary
+		if binary == "" {
+			binary = "ffplay"
+		}
+
+		path, err := exec.LookPath(binary)
+		if err == nil {
+			return &FFplayAlarm{
+				binary: path,
+				debug:  cfg.Debug,
+				sound:  cfg.Sound,
+			}, nil
+		}
+
+		// A missing player should not stop the listener, the timers still announce and log when done.
+		if alarm, wavErr := newWAVAlarm(cfg); wavErr == nil {
+			log.Println(fmt.Sprintf("ffplay binary %q not found, falling back to writing the alarm to %q: %v", binary, cfg.Output, err))
+			return alarm, nil
+		}
+
+		log.Println(fmt.Sprintf("ffplay binary %q not found, falling back to logging the alarm: %v", binary, err))
+		return &LogAlarm{}, nil
+
+	case BackendWAV:
+		return newWAVAlarm(cfg)
+
+	default:
+		return nil, fmt.Errorf("unsupported alarm backend: %q", cfg.Backend)
+	}
+}
+
+// newWAVAlarm creates the WAV alarm.
+func newWAVAlarm(cfg AlarmConfig) (*WAVAlarm, error) {
+	if cfg.Output == "" {
+		return nil, fmt.Errorf("output file is required")
+	}
+
+	if cfg.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+
+	return &WAVAlarm{
+		debug:    cfg.Debug,
+		duration: cfg.Duration,
+		output:   cfg.Output,
+	}, nil
+}
+
+// FFplayAlarm plays the alarm on the speakers using ffplay.
+type FFplayAlarm struct {
+	binary string
+	debug  bool
+	sound  string
+}
+
+// Ring plays the sound in a loop, or beeps, until the context is done.
+func (a *FFplayAlarm) Ring(ctx context.Context) error {
+	args := []string{"-nodisp", "-loglevel", "error"}
+	if a.sound != "" {
+		args = append(args, "-loop", "0", a.sound)
+	} else {
+		args = append(args, "-f", "lavfi", "-i", beepExpr)
+	}
+
+	if a.debug {
+		log.Println(fmt.Sprintf("ringing alarm: %s %q", a.binary, args))
+	}
+
+	cmd := exec.CommandContext(ctx, a.binary, args...)
+	if output, err := cmd.CombinedOutput(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to play alarm: %w: %s", err, bytes.TrimSpace(output))
+	}
+
+	return nil
+}
+
+// WAVAlarm writes the beeps to a WAV file.
+type WAVAlarm struct {
+	debug    bool
+	duration time.Duration
+	output   string
+}
+
+// Ring writes the beeps, replacing the file, and waits until the context is done.
+func (a *WAVAlarm) Ring(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(a.output), 0755); err != nil {
+		return fmt.Errorf("failed to create output dir: %w", err)
+	}
+
+	if err := os.WriteFile(a.output, beeps(a.duration), 0644); err != nil {
+		return fmt.Errorf("failed to write alarm: %w", err)
+	}
+
+	if a.debug {
+		log.Println(fmt.Sprintf("wrote alarm to %q", a.output))
+	}
+
+	<-ctx.Done()
+	return nil
+}
+
+// LogAlarm logs the alarm, when nothing can play or write it.
+type LogAlarm struct{}
+
+// Ring logs the alarm, and waits until the context is done.
+func (a *LogAlarm) Ring(ctx context.Context) error {
+	log.Println("alarm ringing")
+
+	<-ctx.Done()
+	return nil
+}
+
+// beeps returns the beeps as a 16-bit mono WAV file.
+func beeps(duration time.Duration) []byte {
+	samples := int(duration.Seconds() * sampleRate)
+
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+samples*2))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))           // fmt chunk size
+	binary.Write(&b, binary.LittleEndian, uint16(1))            // PCM
+	binary.Write(&b, binary.LittleEndian, uint16(1))            // mono
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate))   // sample rate
+	binary.Write(&b, binary.LittleEndian, uint32(sampleRate*2)) // byte rate
+	binary.Write(&b, binary.LittleEndian, uint16(2))            // block align
+	binary.Write(&b, binary.LittleEndian, uint16(16))           // bits per sample
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(samples*2))
+
+	for i := range samples {
+		t := float64(i) / sampleRate
+
+		var v float64
+		if math.Mod(t, 1) < 0.5 {
+			v = beepVolume * math.Sin(2*math.Pi*beepFrequency*t)
+		}
+
+		binary.Write(&b, binary.LittleEndian, int16(v*math.MaxInt16))
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,89 @@
+package timer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewAlarmWithoutFFplay(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "alarm.wav")
+
+	tests := []struct {
+		name string
+		cfg  AlarmConfig
+		want Alarm
+	}{
+		{
+			name: "falls back to the wav alarm",
+			cfg:  AlarmConfig{Backend: BackendFFplay, Binary: "no-such-ffplay", Duration: time.Second, Output: output},
+			want: &WAVAlarm{duration: time.Second, output: output},
+		},
+		{
+			name: "falls back to logging without an output",
+			cfg:  AlarmConfig{Backend: BackendFFplay, Binary: "no-such-ffplay", Duration: time.Second},
+			want: &LogAlarm{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alarm, err := NewAlarm(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewAlarm() failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(alarm, tt.want) {
+				t.Errorf("NewAlarm() = %#v, want %#v", alarm, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAlarmErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  AlarmConfig
+	}{
+		{name: "unsupported backend", cfg: AlarmConfig{Backend: "speaker"}},
+		{name: "wav without output", cfg: AlarmConfig{Backend: BackendWAV, Duration: time.Second}},
+		{name: "wav without duration", cfg: AlarmConfig{Backend: BackendWAV, Output: "alarm.wav"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAlarm(tt.cfg); err == nil {
+				t.Errorf("NewAlarm(%+v) succeeded, want an error", tt.cfg)
+			}
+		})
+	}
+}
+
+func TestWAVAlarmRing(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "alarms", "alarm.wav")
+
+	alarm, err := NewAlarm(AlarmConfig{Backend: BackendWAV, Duration: 2 * time.Second, Output: output})
+	if err != nil {
+		t.Fatalf("NewAlarm() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := alarm.Ring(ctx); err != nil {
+		t.Fatalf("Ring() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("failed to read alarm: %v", err)
+	}
+
+	// The header, then two seconds of 16-bit samples.
+	if want := 44 + 2*sampleRate*2; len(data) != want || string(data[:4]) != "RIFF" {
+		t.Errorf("alarm is %d bytes starting with %q, want %d bytes starting with %q", len(data), data[:4], want, "RIFF")
+	}
+}
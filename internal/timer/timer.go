@@ -0,0 +1,281 @@
+// Package timer provides kitchen timers that ring an alarm and announce when they are done.
+package timer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotStarted is returned when setting a timer before the timers are started.
+var ErrNotStarted = errors.New("timers are not started")
+
+// Config is the configuration for the timers.
+type Config struct {
+	// Alarm rings when a timer is done.
+	Alarm Alarm
+	// Announcer is the program that speaks the announcements, eg. `spd-say`, empty only logs them.
+	Announcer string
+	// Debug enables logging the timers.
+	Debug bool
+	// RingDuration is how long the alarm rings before stopping by itself.
+	RingDuration time.Duration
+}
+
+// Timer is a running timer.
+type Timer struct {
+	// Name is the name of the timer, eg. `pasta`, empty when unnamed.
+	Name string
+	// Duration is the duration the timer was set for.
+	Duration time.Duration
+	// End is when the timer is done.
+	End time.Time
+}
+
+// Label returns the name of the timer, or its duration when unnamed, eg. `8 minute timer`.
+func (t Timer) Label() string {
+	if t.Name != "" {
+		return fmt.Sprintf("%s timer", t.Name)
+	}
+
+	return fmt.Sprintf("%s timer", strings.TrimSuffix(FormatDuration(t.Duration), "s"))
+}
+
+// Timers runs the kitchen timers.
+type Timers struct {
+	alarm        Alarm
+	announcer    string
+	debug        bool
+	ringDuration time.Duration
+
+	mu       sync.Mutex
+	ctx      context.Context
+	timers   map[*Timer]*time.Timer
+	ringing  uint64
+	stopRing context.CancelFunc
+}
+
+// New creates the timers.
+func New(cfg Config) (*Timers, error) {
+	if cfg.Alarm == nil {
+		return nil, fmt.Errorf("alarm is required")
+	}
+
+	if cfg.RingDuration <= 0 {
+		return nil, fmt.Errorf("ring duration must be positive")
+	}
+
+	announcer := cfg.Announcer
+	if announcer != "" {
+		path, err := exec.LookPath(announcer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find announcer %q: %w", announcer, err)
+		}
+
+		announcer = path
+	}
+
+	return &Timers{
+		alarm:        cfg.Alarm,
+		announcer:    announcer,
+		debug:        cfg.Debug,
+		ringDuration: cfg.RingDuration,
+		timers:       map[*Timer]*time.Timer{},
+	}, nil
+}
+
+// Start runs the timers until the context is done.
+func (t *Timers) Start(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.ctx = ctx
+
+	go func() {
+		<-ctx.Done()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		for _, timer := range t.timers {
+			timer.Stop()
+		}
+	}()
+}
+
+// Set starts a timer, the name is optional.
+func (t *Timers) Set(duration time.Duration, name string) (Timer, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ctx == nil {
+		return Timer{}, ErrNotStarted
+	}
+
+	timer := &Timer{
+		Name:     normalizeName(name),
+		Duration: duration,
+		End:      time.Now().Add(duration),
+	}
+
+	t.timers[timer] = time.AfterFunc(duration, func() {
+		t.done(timer)
+	})
+
+	if t.debug {
+		log.Println(fmt.Sprintf("set %s", timer.Label()))
+	}
+
+	return *timer, nil
+}
+
+// List returns the running timers matching the name, all of them when empty, the soonest first.
+func (t *Timers) List(name string) []Timer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name = normalizeName(name)
+
+	var timers []Timer
+	for timer := range t.timers {
+		if name == "" || timer.Name == name {
+			timers = append(timers, *timer)
+		}
+	}
+
+	slices.SortFunc(timers, func(a, b Timer) int {
+		return a.End.Compare(b.End)
+	})
+
+	return timers
+}
+
+// Cancel cancels the running timers matching the name, all of them when empty, and returns them.
+func (t *Timers) Cancel(name string) []Timer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	name = normalizeName(name)
+
+	var cancelled []Timer
+	for timer, running := range t.timers {
+		if name == "" || timer.Name == name {
+			running.Stop()
+			delete(t.timers, timer)
+			cancelled = append(cancelled, *timer)
+		}
+	}
+
+	return cancelled
+}
+
+// StopAlarm stops the ringing alarm, and reports whether it was ringing.
+func (t *Timers) StopAlarm() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopRing == nil {
+		return false
+	}
+
+	t.stopRing()
+	t.stopRing = nil
+
+	return true
+}
+
+// Announce speaks the text, or only logs it without an announcer.
+func (t *Timers) Announce(ctx context.Context, text string) {
+	log.Println(fmt.Sprintf("announcement: %s", text))
+
+	if t.announcer == "" {
+		return
+	}
+
+	if output, err := exec.CommandContext(ctx, t.announcer, text).CombinedOutput(); err != nil {
+		log.Println(fmt.Sprintf("error announcing %q: %v: %s", text, err, strings.TrimSpace(string(output))))
+	}
+}
+
+// done announces the timer and rings the alarm, unless it is already ringing.
+func (t *Timers) done(timer *Timer) {
+	t.mu.Lock()
+
+	if _, ok := t.timers[timer]; !ok || t.ctx.Err() != nil {
+		t.mu.Unlock()
+		return
+	}
+
+	delete(t.timers, timer)
+
+	ctx := t.ctx
+	if t.stopRing != nil {
+		t.mu.Unlock()
+		t.Announce(ctx, fmt.Sprintf("your %s is done", timer.Label()))
+		return
+	}
+
+	ctx, stopRing := context.WithTimeout(t.ctx, t.ringDuration)
+	defer stopRing()
+
+	t.ringing++
+	id := t.ringing
+	t.stopRing = stopRing
+	t.mu.Unlock()
+
+	t.Announce(ctx, fmt.Sprintf("your %s is done", timer.Label()))
+
+	if err := t.alarm.Ring(ctx); err != nil {
+		log.Println(fmt.Sprintf("error ringing alarm: %v", err))
+	}
+
+	// Forget the alarm, unless it was stopped and another one is ringing.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ringing == id {
+		t.stopRing = nil
+	}
+}
+
+// normalizeName lowercases the name, without the `timer` suffix, eg. `Pasta timer` is `pasta`.
+func normalizeName(name string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), "timer"))
+}
+
+// FormatDuration formats the duration for speaking, eg. `1 minute 30 seconds`.
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+
+	var parts []string
+	for _, unit := range []struct {
+		name string
+		size time.Duration
+	}{
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+		{"second", time.Second},
+	} {
+		n := d / unit.size
+		d -= n * unit.size
+
+		switch {
+		case n == 1:
+			parts = append(parts, fmt.Sprintf("1 %s", unit.name))
+		case n > 1:
+			parts = append(parts, fmt.Sprintf("%d %ss", n, unit.name))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "0 seconds"
+	}
+
+	return strings.Join(parts, " ")
+}
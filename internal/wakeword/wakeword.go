@@ -0,0 +1,291 @@
+// Package wakeword provides a detector for the wake phrases that address Jarvis.
+package wakeword
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultAliases are common whisper mis-transcriptions of "jarvis".
+var DefaultAliases = []string{
+	"jervis",
+	"jarvas",
+	"jarvus",
+	"javis",
+	"travis",
+	"service",
+	"charvis",
+	"garvis",
+	"jarviss",
+}
+
+// Config is the configuration for the detector.
+type Config struct {
+	// Phrases are the wake phrases, eg. `jarvis` or `hey jarvis`.
+	Phrases []string
+	// Aliases are extra phrases matched exactly, eg. known mis-transcriptions.
+	// They are added to the default aliases.
+	Aliases []string
+	// AlwaysListening passes every transcript through, stripping the wake phrase if present.
+	AlwaysListening bool
+	// Debug enables logging the detections.
+	Debug bool
+	// MaxDistance is the maximum edit distance for a fuzzy match of a phrase.
+	MaxDistance int
+}
+
+// Detector detects the wake phrases in transcripts.
+type Detector struct {
+	alwaysListening bool
+	debug           bool
+	maxDistance     int
+
+	// phrases are the wake phrases without spaces, eg. `heyjarvis`.
+	phrases []string
+	// aliases are the aliases without spaces.
+	aliases []string
+	// maxWords is the number of words of the longest phrase.
+	maxWords int
+}
+
+// word is a word of a transcript and its position.
+type word struct {
+	text  string
+	start int
+	end   int
+}
+
+// NewDetector creates a new detector.
+func NewDetector(cfg Config) (*Detector, error) {
+	if len(cfg.Phrases) == 0 {
+		return nil, fmt.Errorf("at least one wake phrase is required")
+	}
+
+	if cfg.MaxDistance < 0 {
+		return nil, fmt.Errorf("max distance must be positive")
+	}
+
+	d := &Detector{
+		alwaysListening: cfg.AlwaysListening,
+		debug:           cfg.Debug,
+		maxDistance:     cfg.MaxDistance,
+	}
+
+	for _, phrase := range cfg.Phrases {
+		words := splitWords(phrase)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("invalid wake phrase: %q", phrase)
+		}
+
+		d.phrases = append(d.phrases, joinWords(words))
+		d.maxWords = max(d.maxWords, len(words))
+	}
+
+	for _, alias := range slices.Concat(DefaultAliases, cfg.Aliases) {
+		words := splitWords(alias)
+		if len(words) == 0 {
+			continue
+		}
+
+		d.aliases = append(d.aliases, joinWords(words))
+		d.maxWords = max(d.maxWords, len(words))
+	}
+
+	return d, nil
+}
+
+// Detect finds the wake phrase in the transcript and returns the text that follows it.
+// It returns false if the transcript does not address Jarvis, unless always listening.
+func (d *Detector) Detect(transcript string) (string, bool) {
+	words := splitWords(transcript)
+
+	// Find the first window of words that matches a wake phrase.
+	// Windows can have one more word than the phrase, eg. whisper splits "jar vis".
+	for i := range words {
+		for n := 1; n <= d.maxWords+1 && i+n <= len(words); n++ {
+			candidate := joinWords(words[i : i+n])
+			if !d.matches(candidate) {
+				continue
+			}
+
+			rest := strings.TrimLeftFunc(transcript[words[i+n-1].end:], func(r rune) bool {
+				return unicode.IsSpace(r) || unicode.IsPunct(r)
+			})
+
+			if d.debug {
+				log.Println(fmt.Sprintf("wake phrase %q detected, command: %q", candidate, rest))
+			}
+
+			return rest, true
+		}
+	}
+
+	if d.alwaysListening {
+		return transcript, true
+	}
+
+	return "", false
+}
+
+// matches checks if the candidate matches a phrase exactly, fuzzily or phonetically, or an alias exactly.
+func (d *Detector) matches(candidate string) bool {
+	if slices.Contains(d.aliases, candidate) {
+		return true
+	}
+
+	for _, phrase := range d.phrases {
+		if candidate == phrase {
+			return true
+		}
+
+		// Short words are too easy to confuse, eg. `jar` and `car`.
+		if len(phrase) < 5 {
+			continue
+		}
+
+		// Soundex only keeps the first consonants, eg. `jarvisit` sounds like `jarvis`.
+		if abs(len(candidate)-len(phrase)) > 1 {
+			continue
+		}
+
+		if levenshtein(candidate, phrase) <= d.maxDistance {
+			return true
+		}
+
+		if soundex(candidate) == soundex(phrase) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitWords splits the text into lowercase words, without punctuation.
+func splitWords(text string) []word {
+	var (
+		words   []word
+		current strings.Builder
+		start   int
+	)
+
+	flush := func(end int) {
+		if current.Len() > 0 {
+			words = append(words, word{text: current.String(), start: start, end: end})
+			current.Reset()
+		}
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if current.Len() == 0 {
+				start = i
+			}
+
+			current.WriteRune(unicode.ToLower(r))
+			continue
+		}
+
+		// Keep contractions together, eg. `what's`.
+		if r == '\'' && current.Len() > 0 {
+			continue
+		}
+
+		flush(i)
+	}
+
+	flush(len(text))
+
+	return words
+}
+
+// joinWords joins the words without spaces.
+func joinWords(words []word) string {
+	var b strings.Builder
+	for _, w := range words {
+		b.WriteString(w.text)
+	}
+
+	return b.String()
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// soundexCodes maps the consonants to their soundex digit.
+var soundexCodes = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// soundex returns the american soundex code of the word, eg. `jarvis` -> `J612`.
+func soundex(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return ""
+	}
+
+	code := []byte{byte(unicode.ToUpper(runes[0]))}
+	last := soundexCodes[runes[0]]
+
+	for _, r := range runes[1:] {
+		digit, ok := soundexCodes[r]
+		switch {
+		case ok && digit != last:
+			code = append(code, digit)
+			last = digit
+		case !ok && r != 'h' && r != 'w':
+			// Vowels separate duplicate codes, `h` and `w` do not.
+			last = 0
+		}
+
+		if len(code) == 4 {
+			break
+		}
+	}
+
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
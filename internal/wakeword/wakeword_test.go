@@ -0,0 +1,161 @@
+package wakeword
+
+import "testing"
+
+// newTestDetector creates a detector with the example.env phrases.
+func newTestDetector(t *testing.T, alwaysListening bool, aliases ...string) *Detector {
+	t.Helper()
+
+	d, err := NewDetector(Config{
+		Phrases:         []string{"jarvis", "hey jarvis"},
+		Aliases:         aliases,
+		AlwaysListening: alwaysListening,
+		MaxDistance:     1,
+	})
+	if err != nil {
+		t.Fatalf("NewDetector() failed: %v", err)
+	}
+
+	return d
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		want       string
+		wantOK     bool
+	}{
+		{name: "exact", transcript: "Jarvis, pause the video.", want: "pause the video.", wantOK: true},
+		{name: "exact phrase of two words", transcript: "Hey Jarvis! Pause the video.", want: "Pause the video.", wantOK: true},
+		{name: "wake word in the middle", transcript: "Okay so Jarvis, play music", want: "play music", wantOK: true},
+		{name: "wake word alone", transcript: "Jarvis?", want: "", wantOK: true},
+		{name: "split by whisper", transcript: "Jar vis, next track", want: "next track", wantOK: true},
+		{name: "levenshtein", transcript: "Jarvi, volume up", want: "volume up", wantOK: true},
+		{name: "levenshtein of the phrase", transcript: "Hey Jervis, mute", want: "mute", wantOK: true},
+		{name: "soundex", transcript: "Jervais, skip ahead", want: "skip ahead", wantOK: true},
+		{name: "alias jervis", transcript: "Jervis, pause", want: "pause", wantOK: true},
+		{name: "alias service", transcript: "Service, pause", want: "pause", wantOK: true},
+		{name: "alias travis", transcript: "Travis, pause", want: "pause", wantOK: true},
+		{name: "custom alias", transcript: "Jarbis, pause", want: "pause", wantOK: true},
+		{name: "first wake word only", transcript: "Jarvis, tell jarvis hi", want: "tell jarvis hi", wantOK: true},
+		{name: "no wake word", transcript: "Pause the video."},
+		{name: "empty", transcript: ""},
+	}
+
+	d := newTestDetector(t, false, "jarbis")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := d.Detect(tt.transcript)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Detect(%q) = %q, %t, want %q, %t", tt.transcript, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDetectNegatives(t *testing.T) {
+	// Common words close to jarvis must not trigger a command.
+	words := []string{
+		"jars", "java", "jive", "jobs", "jealous",
+		"harvest", "harvey", "nervous", "curious", "obvious",
+		"marvin", "davis", "chavez", "carvings", "services",
+		"javascript", "jarvisit", "garbage", "drive", "travel",
+	}
+
+	d := newTestDetector(t, false)
+
+	for _, w := range words {
+		t.Run(w, func(t *testing.T) {
+			transcript := "the " + w + " were on the table"
+			if got, ok := d.Detect(transcript); ok {
+				t.Errorf("Detect(%q) = %q, true, want false", transcript, got)
+			}
+		})
+	}
+}
+
+func TestDetectAlwaysListening(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		want       string
+	}{
+		{name: "without wake word", transcript: "Pause the video.", want: "Pause the video."},
+		{name: "strips the wake word", transcript: "Jarvis, pause the video.", want: "pause the video."},
+		{name: "strips the alias", transcript: "Travis, pause the video.", want: "pause the video."},
+	}
+
+	d := newTestDetector(t, true)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := d.Detect(tt.transcript)
+			if got != tt.want || !ok {
+				t.Errorf("Detect(%q) = %q, %t, want %q, true", tt.transcript, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "jarvis", b: "jarvis", want: 0},
+		{a: "jervis", b: "jarvis", want: 1},
+		{a: "jarvi", b: "jarvis", want: 1},
+		{a: "javis", b: "jarvis", want: 1},
+		{a: "davis", b: "jarvis", want: 2},
+		{a: "", b: "jarvis", want: 6},
+		{a: "café", b: "cafe", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSoundex(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{word: "jarvis", want: "J612"},
+		{word: "jervais", want: "J612"},
+		{word: "service", want: "S612"},
+		{word: "java", want: "J100"},
+		{word: "ashcraft", want: "A261"},
+		{word: "tymczak", want: "T522"},
+		{word: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := soundex(tt.word); got != tt.want {
+			t.Errorf("soundex(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestNewDetectorErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "no phrases", cfg: Config{MaxDistance: 1}},
+		{name: "empty phrase", cfg: Config{Phrases: []string{"jarvis", "!"}, MaxDistance: 1}},
+		{name: "negative distance", cfg: Config{Phrases: []string{"jarvis"}, MaxDistance: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDetector(tt.cfg); err == nil {
+				t.Errorf("NewDetector(%+v) succeeded, want an error", tt.cfg)
+			}
+		})
+	}
+}
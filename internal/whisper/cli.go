@@ -0,0 +1,153 @@
+package whisper
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// CLITranscriber transcribes using the openai-whisper CLI.
+type CLITranscriber struct {
+	debug     bool
+	model     string
+	language  string
+	outputDir string
+	prompt    string
+
+	// command is the command that runs the whisper CLI, eg. `whisper`.
+	command []string
+}
+
+// NewDockerTranscriber creates a transcriber that runs whisper inside the docker compose service.
+func NewDockerTranscriber(ctx context.Context, cfg Config) (*CLITranscriber, error) {
+	// Ensure the whisper service is running.
+	if err := ensureWhisperIsRunning(ctx, cfg.Debug); err != nil {
+		return nil, err
+	}
+
+	return newCLITranscriber(cfg, []string{
+		// Execute a command inside the service.
+		"docker", "compose", "exec", "-T", "whisper",
+		// Run the whisper CLI.
+		"whisper",
+	})
+}
+
+// NewLocalTranscriber creates a transcriber that runs a locally installed whisper CLI.
+func NewLocalTranscriber(cfg Config) (*CLITranscriber, error) {
+	binary, err := lookBinary(cfg.Binary, "whisper")
+	if err != nil {
+		return nil, err
+	}
+
+	return newCLITranscriber(cfg, []string{binary})
+}
+
+// newCLITranscriber creates a transcriber that runs the whisper CLI using the command.
+func newCLITranscriber(cfg Config, command []string) (*CLITranscriber, error) {
+	if cfg.OutputDir == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+
+	if err := createDirIfNotExists(cfg.OutputDir); err != nil {
+		return nil, fmt.Errorf("failed to create output dir: %w", err)
+	}
+
+	return &CLITranscriber{
+		debug:     cfg.Debug,
+		model:     cfg.Model,
+		language:  cfg.Language,
+		outputDir: cfg.OutputDir,
+		prompt:    cfg.Prompt,
+		command:   command,
+	}, nil
+}
+
+// Transcribe transcribes the audio file and returns the transcription.
+func (t *CLITranscriber) Transcribe(ctx context.Context, filePath string) (Transcript, error) {
+	transcriptionPath, err := t.doTranscription(ctx, filePath)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to transcribe audio file: %w", err)
+	}
+
+	var transcript Transcript
+	if err := readTranscription(transcriptionPath, &transcript); err != nil {
+		return Transcript{}, err
+	}
+
+	transcript.Text = strings.TrimSpace(transcript.Text)
+
+	return transcript, nil
+}
+
+// doTranscription transcribes the audio using the whisper CLI and returns the transcription file path.
+func (t *CLITranscriber) doTranscription(ctx context.Context, filePath string) (string, error) {
+	args := []string{
+		// Transcribe the audio file.
+		filePath,
+		// Do not carry over the context.
+		"--condition_on_previous_text", "False",
+		// Specify the model to use.
+		"--model", t.model,
+		// Specify the language to use.
+		"--language", t.language,
+		// Output the results in JSON format, with the segments metadata.
+		"--output_format", "json",
+		// Output the results to the specified directory.
+		"--output_dir", t.outputDir,
+	}
+
+	if t.prompt != "" {
+		args = append(args, "--initial_prompt", t.prompt)
+	}
+
+	args = append(slices.Clone(t.command[1:]), args...)
+
+	cmd := exec.CommandContext(ctx, t.command[0], args...)
+	if t.debug {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("transcription command failed: %w", err)
+	}
+
+	return transcriptionPath(t.outputDir, filePath, "json"), nil
+}
+
+func ensureWhisperIsRunning(ctx context.Context, debug bool) error {
+	// Run docker compose ps, return which services are running.
+	args := []string{"compose", "ps", "--status=running", "--services"}
+	cmd := exec.CommandContext(ctx, "docker", args...)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to check running containers: %w", err)
+	}
+
+	services := strings.Split(string(output), "\n")
+
+	if debug {
+		log.Println(fmt.Sprintf("services running: %s", services))
+	}
+
+	if !slices.Contains(services, "whisper") {
+		return fmt.Errorf("service %q is not running", "whisper")
+	}
+
+	return nil
+}
+
+// transcriptionPath returns the path of the transcription of the audio file, eg. `out/audio.json`.
+func transcriptionPath(outputDir, filePath, ext string) string {
+	audioFilename := filepath.Base(filePath)
+	transcriptionFilename := fmt.Sprintf("%s.%s", strings.TrimSuffix(audioFilename, filepath.Ext(filePath)), ext)
+
+	return filepath.Join(outputDir, transcriptionFilename)
+}
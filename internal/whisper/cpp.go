@@ -0,0 +1,136 @@
+package whisper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// CppTranscriber transcribes using the whisper.cpp CLI.
+type CppTranscriber struct {
+	binary    string
+	debug     bool
+	model     string
+	language  string
+	outputDir string
+	prompt    string
+}
+
+// NewCppTranscriber creates a transcriber that runs a locally installed whisper.cpp CLI.
+func NewCppTranscriber(cfg Config) (*CppTranscriber, error) {
+	binary, err := lookBinary(cfg.Binary, "whisper-cli")
+	if err != nil {
+		return nil, err
+	}
+
+	// whisper.cpp loads the model from a ggml file, eg. `models/ggml-tiny.en.bin`.
+	if _, err := os.Stat(cfg.Model); err != nil {
+		return nil, fmt.Errorf("model must be the path of a ggml model file: %w", err)
+	}
+
+	if cfg.OutputDir == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+
+	if err := createDirIfNotExists(cfg.OutputDir); err != nil {
+		return nil, fmt.Errorf("failed to create output dir: %w", err)
+	}
+
+	return &CppTranscriber{
+		binary:    binary,
+		debug:     cfg.Debug,
+		model:     cfg.Model,
+		language:  cfg.Language,
+		outputDir: cfg.OutputDir,
+		prompt:    cfg.Prompt,
+	}, nil
+}
+
+// cppResult is the JSON output of whisper.cpp.
+type cppResult struct {
+	Result struct {
+		Language string `json:"language"`
+	} `json:"result"`
+	Transcription []struct {
+		Offsets struct {
+			// From is the start of the segment in milliseconds.
+			From int `json:"from"`
+			// To is the end of the segment in milliseconds.
+			To int `json:"to"`
+		} `json:"offsets"`
+		Text string `json:"text"`
+	} `json:"transcription"`
+}
+
+// Transcribe transcribes the audio file and returns the transcription.
+// whisper.cpp does not report the confidence metadata, so it is left as zero.
+func (t *CppTranscriber) Transcribe(ctx context.Context, filePath string) (Transcript, error) {
+	transcriptionPath, err := t.doTranscription(ctx, filePath)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to transcribe audio file: %w", err)
+	}
+
+	var result cppResult
+	if err := readTranscription(transcriptionPath, &result); err != nil {
+		return Transcript{}, err
+	}
+
+	transcript := Transcript{
+		Language: result.Result.Language,
+		Segments: make([]Segment, len(result.Transcription)),
+	}
+
+	texts := make([]string, len(result.Transcription))
+	for i, s := range result.Transcription {
+		transcript.Segments[i] = Segment{
+			ID:    i,
+			Start: float64(s.Offsets.From) / 1000,
+			End:   float64(s.Offsets.To) / 1000,
+			Text:  s.Text,
+		}
+
+		texts[i] = strings.TrimSpace(s.Text)
+	}
+
+	transcript.Text = strings.Join(texts, " ")
+
+	return transcript, nil
+}
+
+// doTranscription transcribes the audio using whisper.cpp and returns the transcription file path.
+func (t *CppTranscriber) doTranscription(ctx context.Context, filePath string) (string, error) {
+	path := transcriptionPath(t.outputDir, filePath, "json")
+
+	args := []string{
+		// Specify the model file to use.
+		"--model", t.model,
+		// Transcribe the audio file.
+		"--file", filePath,
+		// Specify the language to use, whisper.cpp expects lowercase names or codes.
+		"--language", strings.ToLower(t.language),
+		// Output the results in JSON format.
+		"--output-json",
+		// Output the results to the transcription path, without the extension.
+		"--output-file", strings.TrimSuffix(path, ".json"),
+		// Do not print progress and timings.
+		"--no-prints",
+	}
+
+	if t.prompt != "" {
+		args = append(args, "--prompt", t.prompt)
+	}
+
+	cmd := exec.CommandContext(ctx, t.binary, args...)
+	if t.debug {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("transcription command failed: %w", err)
+	}
+
+	return path, nil
+}
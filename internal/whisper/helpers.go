@@ -1,8 +1,10 @@
 package whisper
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"os/exec"
 )
 
 // createDirIfNotExists creates the directory if it does not exist.
@@ -13,3 +15,37 @@ func createDirIfNotExists(dir string) error {
 
 	return nil
 }
+
+// lookBinary finds the binary in the PATH, falling back to the default name.
+func lookBinary(binary, fallback string) (string, error) {
+	if binary == "" {
+		binary = fallback
+	}
+
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("whisper binary %q not found: %w", binary, err)
+	}
+
+	return path, nil
+}
+
+// readTranscription decodes the JSON transcription file into v and removes it.
+func readTranscription(transcriptionPath string, v any) error {
+	// Read the transcription file.
+	data, err := os.ReadFile(transcriptionPath)
+	if err != nil {
+		return fmt.Errorf("failed to read transcription file: %w", err)
+	}
+
+	// Clean up the transcription file.
+	if err := os.Remove(transcriptionPath); err != nil {
+		return fmt.Errorf("failed to delete transcription file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode transcription file: %w", err)
+	}
+
+	return nil
+}
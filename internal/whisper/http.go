@@ -0,0 +1,142 @@
+package whisper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HTTPTranscriber transcribes using an OpenAI-compatible transcription server.
+type HTTPTranscriber struct {
+	apiKey   string
+	debug    bool
+	model    string
+	language string
+	prompt   string
+	url      string
+
+	// client times out, so a stalled server does not block the listener.
+	client *http.Client
+}
+
+// transcriptionError is the error of the transcriptions endpoint.
+type transcriptionError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewHTTPTranscriber creates a transcriber that sends the audio to `/v1/audio/transcriptions`.
+func NewHTTPTranscriber(cfg Config) (*HTTPTranscriber, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	return &HTTPTranscriber{
+		apiKey:   cfg.APIKey,
+		debug:    cfg.Debug,
+		model:    cfg.Model,
+		language: cfg.Language,
+		prompt:   cfg.Prompt,
+		url:      strings.TrimSuffix(cfg.URL, "/"),
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// Transcribe uploads the audio file and returns the transcription.
+func (t *HTTPTranscriber) Transcribe(ctx context.Context, filePath string) (Transcript, error) {
+	req, err := t.buildTranscriptionRequest(ctx, filePath)
+	if err != nil {
+		return Transcript{}, err
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("transcription request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var parsed transcriptionError
+		_ = json.NewDecoder(resp.Body).Decode(&parsed)
+
+		return Transcript{}, fmt.Errorf("transcription request failed with status %d: %s", resp.StatusCode, parsed.Error.Message)
+	}
+
+	// The verbose JSON format matches the whisper JSON output.
+	var transcript Transcript
+	if err := json.NewDecoder(resp.Body).Decode(&transcript); err != nil {
+		return Transcript{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if t.debug {
+		log.Println(fmt.Sprintf("transcription of %s: %s", filePath, transcript.Text))
+	}
+
+	transcript.Text = strings.TrimSpace(transcript.Text)
+
+	return transcript, nil
+}
+
+// buildTranscriptionRequest builds a multipart request for the transcriptions endpoint.
+func (t *HTTPTranscriber) buildTranscriptionRequest(ctx context.Context, filePath string) (*http.Request, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy audio file: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":           t.model,
+		"language":        t.language,
+		"response_format": "verbose_json",
+	}
+
+	if t.prompt != "" {
+		fields["prompt"] = t.prompt
+	}
+
+	for key, value := range fields {
+		if err := writer.WriteField(key, value); err != nil {
+			return nil, fmt.Errorf("failed to write form field %q: %w", key, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close form: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/v1/audio/transcriptions", t.url)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.apiKey))
+	}
+
+	return req, nil
+}
@@ -0,0 +1,136 @@
+package whisper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestAudio writes a placeholder audio file, the http backend uploads it as is.
+func newTestAudio(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "combined_1.wav")
+	if err := os.WriteFile(path, []byte("RIFF audio"), 0644); err != nil {
+		t.Fatalf("failed to write audio: %v", err)
+	}
+
+	return path
+}
+
+// newTestHTTPTranscriber creates a transcriber of the server.
+func newTestHTTPTranscriber(t *testing.T, url string, timeout time.Duration) *HTTPTranscriber {
+	t.Helper()
+
+	transcriber, err := NewHTTPTranscriber(Config{
+		Model:    "whisper-1",
+		Language: "en",
+		Prompt:   "Jarvis",
+		Timeout:  timeout,
+		URL:      url + "/",
+	})
+	if err != nil {
+		t.Fatalf("failed to create transcriber: %v", err)
+	}
+
+	return transcriber
+}
+
+func TestHTTPTranscriber(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+
+		fields := fmt.Sprintf("%s %s %s %s", r.FormValue("model"), r.FormValue("language"), r.FormValue("prompt"), r.FormValue("response_format"))
+		if fields != "whisper-1 en Jarvis verbose_json" {
+			http.Error(w, fields, http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"text":     " Jarvis, pause the video. ",
+			"language": "english",
+			"segments": []map[string]any{{"id": 0, "text": " Jarvis, pause the video.", "avg_logprob": -0.2, "no_speech_prob": 0.01}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	transcript, err := newTestHTTPTranscriber(t, server.URL, time.Second).Transcribe(context.Background(), newTestAudio(t))
+	if err != nil {
+		t.Fatalf("Transcribe() failed: %v", err)
+	}
+
+	if transcript.Text != "Jarvis, pause the video." || len(transcript.Segments) != 1 || transcript.Segments[0].AvgLogprob != -0.2 {
+		t.Errorf("Transcribe() = %+v", transcript)
+	}
+}
+
+func TestHTTPTranscriberErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr string
+	}{
+		{
+			name: "error status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				fmt.Fprint(w, `{"error":{"message":"invalid audio"}}`)
+			},
+			wantErr: "status 422: invalid audio",
+		},
+		{
+			name: "invalid body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, "not json")
+			},
+			wantErr: "failed to decode response",
+		},
+		{
+			name: "stalled server",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				// Reading the whole upload lets the server notice the client giving up.
+				io.Copy(io.Discard, r.Body)
+				<-r.Context().Done()
+			},
+			wantErr: "Client.Timeout exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			t.Cleanup(server.Close)
+
+			transcriber := newTestHTTPTranscriber(t, server.URL, 100*time.Millisecond)
+
+			// The listener context never expires, only the timeout stops a stalled server.
+			start := time.Now()
+			_, err := transcriber.Transcribe(context.Background(), newTestAudio(t))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Transcribe() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Transcribe() took %s, want it to time out", elapsed)
+			}
+		})
+	}
+}
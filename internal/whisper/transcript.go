@@ -0,0 +1,100 @@
+package whisper
+
+import "strings"
+
+// Whisper thresholds, see `whisper.transcribe` in openai-whisper.
+const (
+	// NoSpeechThreshold is the no-speech probability above which a segment may be silence.
+	NoSpeechThreshold = 0.6
+	// LogprobThreshold is the average log probability below which a segment is not confident.
+	LogprobThreshold = -1.0
+)
+
+// Transcript is the transcription of an audio file.
+type Transcript struct {
+	// Text is the full transcription.
+	Text string `json:"text"`
+	// Language is the detected or configured language.
+	Language string `json:"language"`
+	// Segments are the timestamped parts of the transcription.
+	Segments []Segment `json:"segments"`
+}
+
+// Segment is a timestamped part of a transcription.
+// Backends that don't report a metric leave it as zero, eg. whisper.cpp.
+type Segment struct {
+	// ID is the index of the segment.
+	ID int `json:"id"`
+	// Start is the start of the segment in seconds.
+	Start float64 `json:"start"`
+	// End is the end of the segment in seconds.
+	End float64 `json:"end"`
+	// Text is the transcription of the segment.
+	Text string `json:"text"`
+	// AvgLogprob is the average log probability of the tokens, closer to 0 is more confident.
+	AvgLogprob float64 `json:"avg_logprob"`
+	// NoSpeechProb is the probability that the segment has no speech.
+	NoSpeechProb float64 `json:"no_speech_prob"`
+	// CompressionRatio is the gzip compression ratio of the text, high values mean repetitions.
+	CompressionRatio float64 `json:"compression_ratio"`
+}
+
+// IsSilence checks if the segment is likely silence, using whisper's own rule.
+func (s Segment) IsSilence() bool {
+	return s.NoSpeechProb > NoSpeechThreshold && s.AvgLogprob < LogprobThreshold
+}
+
+// IsSilence checks if the transcript is empty or every segment is likely silence.
+func (t Transcript) IsSilence() bool {
+	if strings.TrimSpace(t.Text) == "" {
+		return true
+	}
+
+	if len(t.Segments) == 0 {
+		return false
+	}
+
+	for _, s := range t.Segments {
+		if !s.IsSilence() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AvgLogprob returns the duration-weighted average log probability of the segments.
+func (t Transcript) AvgLogprob() float64 {
+	var sum, total float64
+	for _, s := range t.Segments {
+		d := max(s.End-s.Start, 0.01)
+		sum += s.AvgLogprob * d
+		total += d
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return sum / total
+}
+
+// MaxNoSpeechProb returns the highest no-speech probability of the segments.
+func (t Transcript) MaxNoSpeechProb() float64 {
+	var m float64
+	for _, s := range t.Segments {
+		m = max(m, s.NoSpeechProb)
+	}
+
+	return m
+}
+
+// MaxCompressionRatio returns the highest compression ratio of the segments.
+func (t Transcript) MaxCompressionRatio() float64 {
+	var m float64
+	for _, s := range t.Segments {
+		m = max(m, s.CompressionRatio)
+	}
+
+	return m
+}
@@ -1,22 +1,48 @@
-// Package whisper provides a transcriber for audio files.
+// Package whisper provides transcribers for audio files.
 package whisper
 
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"slices"
-	"strings"
+	"time"
 )
 
-// ClientConfig is the configuration for a Client.
-type ClientConfig struct {
+// Backends.
+const (
+	// BackendDocker runs the whisper CLI inside the docker compose service.
+	BackendDocker = "docker"
+	// BackendLocal runs a locally installed whisper CLI.
+	BackendLocal = "local"
+	// BackendCpp runs a locally installed whisper.cpp CLI.
+	BackendCpp = "cpp"
+	// BackendHTTP sends the audio to an OpenAI-compatible transcription server.
+	BackendHTTP = "http"
+	// BackendWorker runs a persistent whisper worker inside the docker compose service.
+	BackendWorker = "worker"
+	// BackendLocalWorker runs a persistent whisper worker with a local python.
+	BackendLocalWorker = "local-worker"
+)
+
+// Transcriber transcribes audio files.
+type Transcriber interface {
+	// Transcribe transcribes the audio file and returns the transcription with its metadata.
+	Transcribe(ctx context.Context, filePath string) (Transcript, error)
+}
+
+// Config is the configuration for a Transcriber.
+type Config struct {
+	// Backend is the backend of the transcriber, eg. `docker`, `local`, `cpp`, `http`, or `worker`.
+	Backend string
+	// Binary is the path of the whisper binary, for the local and cpp backends,
+	// or of the python interpreter, for the local-worker backend.
+	Binary string
+	// URL is the URL of the transcription server, for the http backend.
+	URL string
+	// APIKey is the optional API key of the transcription server, for the http backend.
+	APIKey string
 	// Debug enables logging while transcribing.
 	Debug bool
-	// Model is the name of the model to use.
+	// Model is the name of the model to use, or its path for the cpp backend.
 	Model string
 	// Language is the language to use.
 	Language string
@@ -24,24 +50,12 @@ type ClientConfig struct {
 	OutputDir string
 	// Prompt is the prompt to use for the transcription.
 	Prompt string
+	// Timeout is how long a transcription may take, for the http and worker backends, zero waits until the context is done.
+	Timeout time.Duration
 }
 
-// Client is a client for the whisper service.
-type Client struct {
-	debug     bool
-	model     string
-	language  string
-	outputDir string
-	prompt    string
-}
-
-// NewClient creates a new Client.
-func NewClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
-	// Ensure the whisper service is running.
-	if err := ensureWhisperIsRunning(ctx, cfg.Debug); err != nil {
-		return nil, err
-	}
-
+// New creates the transcriber for the backend.
+func New(ctx context.Context, cfg Config) (Transcriber, error) {
 	if cfg.Model == "" {
 		return nil, fmt.Errorf("model is required")
 	}
@@ -50,106 +64,41 @@ func NewClient(ctx context.Context, cfg ClientConfig) (*Client, error) {
 		return nil, fmt.Errorf("language is required")
 	}
 
-	if cfg.OutputDir == "" {
-		return nil, fmt.Errorf("output directory is required")
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative")
 	}
 
-	if err := createDirIfNotExists(cfg.OutputDir); err != nil {
-		return nil, fmt.Errorf("failed to create output dir: %w", err)
-	}
-
-	return &Client{
-		debug:     cfg.Debug,
-		model:     cfg.Model,
-		language:  cfg.Language,
-		outputDir: cfg.OutputDir,
-		prompt:    cfg.Prompt,
-	}, nil
-}
-
-// Transcribe transcribes the audio file and returns the transcription.
-func (t *Client) Transcribe(ctx context.Context, filePath string) (string, error) {
-	transcriptionPath, err := t.doTranscription(ctx, filePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to transcribe audio file: %w", err)
-	}
-
-	// Read the transcription file.
-	untrimmedTranscript, err := os.ReadFile(transcriptionPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read transcription file: %w", err)
-	}
+	var (
+		transcriber Transcriber
+		err         error
+	)
 
-	// Clean up the transcription file.
-	if err := os.Remove(transcriptionPath); err != nil {
-		return "", fmt.Errorf("failed to delete transcription file: %w", err)
-	}
+	switch cfg.Backend {
+	case BackendDocker:
+		transcriber, err = NewDockerTranscriber(ctx, cfg)
 
-	transcript := strings.TrimSpace(string(untrimmedTranscript))
+	case BackendLocal:
+		transcriber, err = NewLocalTranscriber(cfg)
 
-	return transcript, nil
-}
+	case BackendCpp:
+		transcriber, err = NewCppTranscriber(cfg)
 
-// doTranscription transcribes the audio using the whisper service and returns the transcription file path.
-func (t *Client) doTranscription(ctx context.Context, filePath string) (string, error) {
-	args := []string{
-		// Execute a command inside the service.
-		"compose", "exec", "-T", "whisper",
-		// Transcribe the audio file.
-		"whisper", filePath,
-		// Do not carry over the context.
-		"--condition_on_previous_text", "False",
-		// Specify the model to use.
-		"--model", t.model,
-		// Specify the language to use.
-		"--language", t.language,
-		// Output the results in text format.
-		"--output_format", "txt",
-		// Output the results to the specified directory.
-		"--output_dir", t.outputDir,
-	}
+	case BackendHTTP:
+		transcriber, err = NewHTTPTranscriber(cfg)
 
-	if t.prompt != "" {
-		args = append(args, "--initial_prompt", t.prompt)
-	}
+	case BackendWorker:
+		transcriber, err = NewDockerWorkerTranscriber(ctx, cfg)
 
-	cmd := exec.CommandContext(ctx, "docker", args...)
-	if t.debug {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-	}
+	case BackendLocalWorker:
+		transcriber, err = NewLocalWorkerTranscriber(ctx, cfg)
 
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("transcription command failed: %w", err)
+	default:
+		return nil, fmt.Errorf("unsupported whisper backend: %q", cfg.Backend)
 	}
 
-	audioFilename := filepath.Base(filePath)
-	transcriptionFilename := fmt.Sprintf("%s.txt", strings.TrimSuffix(audioFilename, filepath.Ext(filePath)))
-
-	transcriptionPath := filepath.Join(t.outputDir, transcriptionFilename)
-
-	return transcriptionPath, nil
-}
-
-func ensureWhisperIsRunning(ctx context.Context, debug bool) error {
-	// Run docker compose ps, return which services are running.
-	args := []string{"compose", "ps", "--status=running", "--services"}
-	cmd := exec.CommandContext(ctx, "docker", args...)
-
-	output, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to check running containers: %w", err)
-	}
-
-	services := strings.Split(string(output), "\n")
-
-	if debug {
-		log.Println(fmt.Sprintf("services running: %s", services))
-	}
-
-	if !slices.Contains(services, "whisper") {
-		return fmt.Errorf("service %q is not running", "whisper")
+		return nil, err
 	}
 
-	return nil
+	return transcriber, nil
 }
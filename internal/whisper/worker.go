@@ -0,0 +1,389 @@
+package whisper
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Worker defaults.
+const (
+	// workerStartTimeout is how long the worker has to load the model.
+	workerStartTimeout = 2 * time.Minute
+	// workerMinRestartDelay is the delay before restarting a crashed worker.
+	workerMinRestartDelay = time.Second
+	// workerMaxRestartDelay caps the delay between restarts of a crash-looping worker.
+	workerMaxRestartDelay = 30 * time.Second
+	// workerResponsesBuffer is the number of responses kept until they are read.
+	workerResponsesBuffer = 8
+	// workerIdleCheck is how long the worker stays idle before it is pinged, before its next request.
+	workerIdleCheck = time.Minute
+	// workerPingTimeout is how long the worker has to answer a ping.
+	workerPingTimeout = 5 * time.Second
+)
+
+// WorkerTranscriber transcribes using a long-lived whisper worker that keeps the model loaded.
+// See `infra/whisper/worker.py` for the protocol.
+type WorkerTranscriber struct {
+	debug    bool
+	model    string
+	language string
+	prompt   string
+	// timeout is how long a transcription may take, before the worker is restarted.
+	timeout time.Duration
+
+	// command is the command that runs the worker, without the model flag.
+	command []string
+	// ctx is the lifetime of the worker processes.
+	ctx context.Context
+
+	// mu serializes the requests, the worker transcribes one audio at a time.
+	mu           sync.Mutex
+	proc         *workerProcess
+	nextID       int
+	restartDelay time.Duration
+	restartAt    time.Time
+	// lastActive is when the worker last answered, to check it after being idle.
+	lastActive time.Time
+}
+
+// workerProcess is a running worker.
+type workerProcess struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	responses chan workerResponse
+	// exited is closed when the process exits.
+	exited chan struct{}
+	err    error
+}
+
+// workerRequest is a request to the worker.
+type workerRequest struct {
+	ID       string `json:"id"`
+	Audio    string `json:"audio,omitempty"`
+	Language string `json:"language,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+	Ping     bool   `json:"ping,omitempty"`
+}
+
+// workerResponse is a response from the worker.
+type workerResponse struct {
+	ID     string          `json:"id"`
+	Ready  bool            `json:"ready"`
+	Pong   bool            `json:"pong"`
+	Error  string          `json:"error"`
+	Result json.RawMessage `json:"result"`
+}
+
+// NewDockerWorkerTranscriber creates a transcriber that runs the worker inside the docker compose service.
+func NewDockerWorkerTranscriber(ctx context.Context, cfg Config) (*WorkerTranscriber, error) {
+	// Ensure the whisper service is running.
+	if err := ensureWhisperIsRunning(ctx, cfg.Debug); err != nil {
+		return nil, err
+	}
+
+	return newWorkerTranscriber(ctx, cfg, []string{
+		// Execute a command inside the service, keeping stdin open.
+		"docker", "compose", "exec", "-T", "whisper",
+		// Run the worker installed by the Dockerfile.
+		"python", "/opt/jarvis/worker.py",
+	})
+}
+
+// NewLocalWorkerTranscriber creates a transcriber that runs the worker with a local python.
+func NewLocalWorkerTranscriber(ctx context.Context, cfg Config) (*WorkerTranscriber, error) {
+	python, err := lookBinary(cfg.Binary, "python3")
+	if err != nil {
+		return nil, err
+	}
+
+	return newWorkerTranscriber(ctx, cfg, []string{python, "infra/whisper/worker.py"})
+}
+
+// newWorkerTranscriber creates a transcriber and waits for its worker to load the model.
+func newWorkerTranscriber(ctx context.Context, cfg Config, command []string) (*WorkerTranscriber, error) {
+	t := &WorkerTranscriber{
+		debug:        cfg.Debug,
+		model:        cfg.Model,
+		language:     cfg.Language,
+		prompt:       cfg.Prompt,
+		timeout:      cfg.Timeout,
+		command:      command,
+		ctx:          ctx,
+		restartDelay: workerMinRestartDelay,
+	}
+
+	// Start the worker eagerly, so the model is warm for the first transcription.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.start(); err != nil {
+		return nil, fmt.Errorf("failed to start whisper worker: %w", err)
+	}
+
+	return t, nil
+}
+
+// Transcribe sends the audio file to the worker and returns the transcription.
+func (t *WorkerTranscriber) Transcribe(ctx context.Context, filePath string) (Transcript, error) {
+	audio, err := os.ReadFile(filePath)
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	resp, err := t.do(ctx, workerRequest{
+		Audio:    base64.StdEncoding.EncodeToString(audio),
+		Language: t.language,
+		Prompt:   t.prompt,
+	})
+	if err != nil {
+		return Transcript{}, fmt.Errorf("failed to transcribe audio file: %w", err)
+	}
+
+	// The worker result is the whisper JSON output.
+	var transcript Transcript
+	if err := json.Unmarshal(resp.Result, &transcript); err != nil {
+		return Transcript{}, fmt.Errorf("failed to decode transcription: %w", err)
+	}
+
+	transcript.Text = strings.TrimSpace(transcript.Text)
+
+	return transcript, nil
+}
+
+// do sends the request to the worker, restarting it if needed, and waits for its response.
+// A worker idle for a while is pinged first, and restarted if it hung, eg. its container was paused.
+// A worker that takes longer than the timeout is stopped, so the next request restarts it.
+func (t *WorkerTranscriber) do(ctx context.Context, req workerRequest) (workerResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.ensureRunning(); err != nil {
+		return workerResponse{}, err
+	}
+
+	if time.Since(t.lastActive) > workerIdleCheck {
+		if err := t.healthcheck(ctx); err != nil {
+			if ctx.Err() != nil {
+				return workerResponse{}, ctx.Err()
+			}
+
+			log.Println(fmt.Sprintf("whisper worker is unresponsive, restarting: %s", err))
+			t.stop()
+
+			if err := t.ensureRunning(); err != nil {
+				return workerResponse{}, err
+			}
+		}
+	}
+
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+
+	resp, err := t.send(ctx, req)
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Println(fmt.Sprintf("whisper worker did not answer in %s, restarting", t.timeout))
+		t.stop()
+	}
+
+	return resp, err
+}
+
+// healthcheck checks that the worker answers a ping in time.
+func (t *WorkerTranscriber) healthcheck(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, workerPingTimeout)
+	defer cancel()
+
+	_, err := t.send(ctx, workerRequest{Ping: true})
+	return err
+}
+
+// send sends the request to the running worker and waits for its response.
+func (t *WorkerTranscriber) send(ctx context.Context, req workerRequest) (workerResponse, error) {
+	t.nextID++
+	req.ID = strconv.Itoa(t.nextID)
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return workerResponse{}, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	proc := t.proc
+	if _, err := proc.stdin.Write(append(data, '\n')); err != nil {
+		return workerResponse{}, fmt.Errorf("failed to write to whisper worker: %w", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return workerResponse{}, ctx.Err()
+
+		case <-proc.exited:
+			return workerResponse{}, fmt.Errorf("whisper worker exited: %w", proc.err)
+
+		case resp := <-proc.responses:
+			// Skip stale responses of requests whose context was cancelled.
+			if resp.ID != req.ID {
+				continue
+			}
+
+			t.lastActive = time.Now()
+
+			if resp.Error != "" {
+				return workerResponse{}, fmt.Errorf("whisper worker error: %s", resp.Error)
+			}
+
+			return resp, nil
+		}
+	}
+}
+
+// stop kills the worker, eg. when it hung, so the next request restarts it.
+func (t *WorkerTranscriber) stop() {
+	if t.proc == nil {
+		return
+	}
+
+	_ = t.proc.cmd.Process.Kill()
+	t.proc = nil
+}
+
+// ensureRunning restarts the worker if it exited, backing off while it crash loops.
+func (t *WorkerTranscriber) ensureRunning() error {
+	if t.proc != nil {
+		select {
+		case <-t.proc.exited:
+			log.Println(fmt.Sprintf("whisper worker exited: %s", t.proc.err))
+			t.proc = nil
+		default:
+			return nil
+		}
+	}
+
+	if wait := time.Until(t.restartAt); wait > 0 {
+		return fmt.Errorf("whisper worker restarting in %s", wait.Round(time.Millisecond))
+	}
+
+	if err := t.start(); err != nil {
+		return fmt.Errorf("failed to restart whisper worker: %w", err)
+	}
+
+	log.Println("whisper worker restarted")
+
+	return nil
+}
+
+// start starts the worker and waits for it to load the model.
+func (t *WorkerTranscriber) start() error {
+	// Back off if the worker crashes again soon.
+	t.restartAt = time.Now().Add(t.restartDelay)
+	t.restartDelay = min(t.restartDelay*2, workerMaxRestartDelay)
+
+	args := append(slices.Clone(t.command[1:]), "--model", t.model)
+	cmd := exec.CommandContext(t.ctx, t.command[0], args...)
+	if t.debug {
+		cmd.Stderr = os.Stderr
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdin: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+
+	proc := &workerProcess{
+		cmd:       cmd,
+		stdin:     stdin,
+		responses: make(chan workerResponse, workerResponsesBuffer),
+		exited:    make(chan struct{}),
+	}
+
+	go proc.readResponses(stdout, t.debug)
+
+	// Wait for the model to load.
+	select {
+	case <-time.After(workerStartTimeout):
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("whisper worker not ready after %s", workerStartTimeout)
+
+	case <-proc.exited:
+		return fmt.Errorf("whisper worker exited before ready: %w", proc.err)
+
+	case resp := <-proc.responses:
+		if !resp.Ready {
+			_ = cmd.Process.Kill()
+			return fmt.Errorf("whisper worker sent %+v before ready", resp)
+		}
+	}
+
+	// The worker is healthy, reset the backoff.
+	t.proc = proc
+	t.restartDelay = workerMinRestartDelay
+	t.lastActive = time.Now()
+
+	if t.debug {
+		log.Println(fmt.Sprintf("whisper worker ready: %s", strings.Join(args, " ")))
+	}
+
+	return nil
+}
+
+// readResponses forwards the responses of the worker until it exits.
+func (p *workerProcess) readResponses(stdout io.Reader, debug bool) {
+	scanner := bufio.NewScanner(stdout)
+	// Transcription results can be longer than the default 64KB line limit.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var resp workerResponse
+		if err := json.Unmarshal(scanner.Bytes(), &resp); err != nil {
+			if debug {
+				log.Println(fmt.Sprintf("whisper worker sent invalid response: %s", err))
+			}
+
+			continue
+		}
+
+		select {
+		case p.responses <- resp:
+		default:
+			// Nobody is waiting for the response, eg. its request timed out.
+			if debug {
+				log.Println(fmt.Sprintf("whisper worker response %q dropped", resp.ID))
+			}
+		}
+	}
+
+	p.err = p.cmd.Wait()
+	if p.err == nil {
+		p.err = scanner.Err()
+	}
+	if p.err == nil {
+		p.err = io.EOF
+	}
+
+	close(p.exited)
+}
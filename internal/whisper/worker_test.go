@@ -0,0 +1,298 @@
+package whisper
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nizarmah/jarvis/internal/ffmpeg"
+)
+
+// fakeWorkerEnv runs the test binary as a fake worker, see `runFakeWorker`.
+const fakeWorkerEnv = "JARVIS_FAKE_WORKER"
+
+// fakeWorkerHangEnv is a marker file, the fake worker hangs on its first audio until the marker exists.
+const fakeWorkerHangEnv = "JARVIS_FAKE_WORKER_HANG"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeWorkerEnv) != "" {
+		runFakeWorker()
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// runFakeWorker speaks the protocol of `infra/whisper/worker.py`.
+// Like the real worker, it only reads 16 kHz mono 16-bit pcm wav.
+func runFakeWorker() {
+	encoder := json.NewEncoder(os.Stdout)
+	_ = encoder.Encode(workerResponse{Ready: true})
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var req workerRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			continue
+		}
+
+		if req.Ping {
+			_ = encoder.Encode(workerResponse{ID: req.ID, Pong: true})
+			continue
+		}
+
+		if marker := os.Getenv(fakeWorkerHangEnv); marker != "" {
+			if _, err := os.Stat(marker); os.IsNotExist(err) {
+				_ = os.WriteFile(marker, nil, 0644)
+				time.Sleep(time.Hour)
+			}
+		}
+
+		audio, _ := base64.StdEncoding.DecodeString(req.Audio)
+		seconds, err := pcmSeconds(audio)
+		if err != nil {
+			_ = encoder.Encode(workerResponse{ID: req.ID, Error: err.Error()})
+			continue
+		}
+
+		result, _ := json.Marshal(Transcript{Text: fmt.Sprintf(" %.1fs of audio ", seconds)})
+		_ = encoder.Encode(workerResponse{ID: req.ID, Result: result})
+	}
+}
+
+// pcmSeconds returns the duration of a 16 kHz mono 16-bit pcm wav.
+func pcmSeconds(audio []byte) (float64, error) {
+	errFormat := errors.New("expected a 16 kHz mono 16-bit wav")
+
+	if len(audio) < 12 || string(audio[:4]) != "RIFF" || string(audio[8:12]) != "WAVE" {
+		return 0, errFormat
+	}
+
+	isPCM := false
+	for offset := 12; offset+8 <= len(audio); {
+		id := string(audio[offset : offset+4])
+		size := int(binary.LittleEndian.Uint32(audio[offset+4 : offset+8]))
+		body := audio[offset+8 : min(offset+8+size, len(audio))]
+
+		switch id {
+		case "fmt ":
+			isPCM = len(body) >= 16 &&
+				binary.LittleEndian.Uint16(body[0:2]) == 1 &&
+				binary.LittleEndian.Uint16(body[2:4]) == 1 &&
+				binary.LittleEndian.Uint32(body[4:8]) == 16000 &&
+				binary.LittleEndian.Uint16(body[14:16]) == 16
+		case "data":
+			if !isPCM {
+				return 0, errFormat
+			}
+
+			return float64(len(body)) / (16000 * 2), nil
+		}
+
+		offset += 8 + size + size%2
+	}
+
+	return 0, errFormat
+}
+
+// writeTestWAV writes a wav of silence with the format.
+func writeTestWAV(t *testing.T, audioFormat uint16, sampleRate uint32, seconds int) string {
+	t.Helper()
+
+	data := make([]byte, int(sampleRate)*2*seconds)
+
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+len(data)))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], audioFormat)
+	binary.LittleEndian.PutUint16(header[22:], 1)
+	binary.LittleEndian.PutUint32(header[24:], sampleRate)
+	binary.LittleEndian.PutUint32(header[28:], sampleRate*2)
+	binary.LittleEndian.PutUint16(header[32:], 2)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(len(data)))
+
+	path := filepath.Join(t.TempDir(), "combined_0.wav")
+	if err := os.WriteFile(path, append(header, data...), 0644); err != nil {
+		t.Fatalf("failed to write wav: %v", err)
+	}
+
+	return path
+}
+
+// newTestWorkerTranscriber creates a transcriber running the fake worker.
+func newTestWorkerTranscriber(t *testing.T, timeout time.Duration) *WorkerTranscriber {
+	t.Helper()
+
+	t.Setenv(fakeWorkerEnv, "1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	transcriber, err := newWorkerTranscriber(ctx, Config{Model: "tiny.en", Language: "en", Timeout: timeout}, []string{os.Args[0]})
+	if err != nil {
+		t.Fatalf("failed to create transcriber: %v", err)
+	}
+
+	return transcriber
+}
+
+func TestWorkerTranscriber(t *testing.T) {
+	tests := []struct {
+		name    string
+		audio   func(t *testing.T) string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "pcm wav",
+			audio: func(t *testing.T) string { return writeTestWAV(t, 1, 16000, 2) },
+			want:  "2.0s of audio",
+		},
+		{
+			name:    "aac in a wav container",
+			audio:   func(t *testing.T) string { return writeTestWAV(t, 0xff, 16000, 2) },
+			wantErr: "whisper worker error: expected a 16 kHz mono 16-bit wav",
+		},
+		{
+			name:    "wrong sample rate",
+			audio:   func(t *testing.T) string { return writeTestWAV(t, 1, 44100, 1) },
+			wantErr: "whisper worker error: expected a 16 kHz mono 16-bit wav",
+		},
+	}
+
+	transcriber := newTestWorkerTranscriber(t, 0)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transcript, err := transcriber.Transcribe(context.Background(), tt.audio(t))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Transcribe() error = %v, want %q", err, tt.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Transcribe() failed: %v", err)
+			}
+
+			if transcript.Text != tt.want {
+				t.Errorf("Transcribe() = %q, want %q", transcript.Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkerTranscriberCombinedFile(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg is not installed")
+	}
+
+	// Record a chunk like the recorder, then let the combiner convert it.
+	chunkPath := filepath.Join(t.TempDir(), "chunk_0.aac")
+	cmd := exec.Command("ffmpeg", "-loglevel", "error", "-y",
+		"-f", "lavfi", "-i", "sine=frequency=440:duration=2",
+		"-acodec", "aac", "-ar", "16000", "-ac", "1", "-f", "adts", chunkPath,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to record chunk: %v: %s", err, output)
+	}
+
+	chunk, err := os.ReadFile(chunkPath)
+	if err != nil {
+		t.Fatalf("failed to read chunk: %v", err)
+	}
+
+	transcriber := newTestWorkerTranscriber(t, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	results := make(chan string, 1)
+	errs := make(chan error, 1)
+
+	inputDir := t.TempDir()
+	combiner, err := ffmpeg.NewCombiner(ffmpeg.CombinerConfig{
+		ChunksNum: 6,
+		InputDir:  inputDir,
+		OutputDir: t.TempDir(),
+		OnCombined: func(ctx context.Context, filePath string) error {
+			transcript, err := transcriber.Transcribe(ctx, filePath)
+			if err != nil {
+				errs <- err
+				return nil
+			}
+
+			results <- transcript.Text
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewCombiner() failed: %v", err)
+	}
+
+	if err := combiner.Start(ctx); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(inputDir, "chunk_0.aac"), chunk, 0644); err != nil {
+		t.Fatalf("failed to write chunk: %v", err)
+	}
+
+	select {
+	case text := <-results:
+		if !strings.HasSuffix(text, "s of audio") {
+			t.Errorf("Transcribe() = %q, want the duration of the audio", text)
+		}
+	case err := <-errs:
+		t.Fatalf("Transcribe() failed: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("combiner did not combine the chunk")
+	}
+}
+
+func TestWorkerTranscriberTimeoutRestarts(t *testing.T) {
+	t.Setenv(fakeWorkerHangEnv, filepath.Join(t.TempDir(), "hung"))
+
+	transcriber := newTestWorkerTranscriber(t, 200*time.Millisecond)
+	audio := writeTestWAV(t, 1, 16000, 1)
+
+	// The listener context never expires, only the timeout stops a hung worker.
+	start := time.Now()
+	if _, err := transcriber.Transcribe(context.Background(), audio); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Transcribe() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Transcribe() took %s, want it to time out", elapsed)
+	}
+
+	// Wait for the restart backoff, the next request restarts the worker.
+	time.Sleep(workerMinRestartDelay)
+
+	transcript, err := transcriber.Transcribe(context.Background(), audio)
+	if err != nil {
+		t.Fatalf("Transcribe() after the timeout failed: %v", err)
+	}
+
+	if transcript.Text != "1.0s of audio" {
+		t.Errorf("Transcribe() after the timeout = %q, want %q", transcript.Text, "1.0s of audio")
+	}
+}